@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"log"
 
@@ -31,30 +30,42 @@ func main() {
 	}
 	log.Println(status)
 
-	// Shorten long URL
-	shortenURL := &kbapi.ShortenURL{
-		URL: "/app/kibana#/dashboard?_g=()&_a=(description:'',filters:!(),fullScreenMode:!f,options:(hidePanelTitles:!f,useMargins:!t),panels:!((embeddableConfig:(),gridData:(h:15,i:'1',w:24,x:0,y:0),id:'8f4d0c00-4c86-11e8-b3d7-01146121b73d',panelIndex:'1',type:visualization,version:'7.0.0-alpha1')),query:(language:lucene,query:''),timeRestore:!f,title:'New%20Dashboard',viewMode:edit)",
+	// Shorten dashboard URL
+	dashboardParams := &kbapi.DashboardLocatorParams{
+		DashboardID: "edf84fe0-e1a0-11e7-b6d5-4dc382ef7f5b",
+		TimeRange: &kbapi.LocatorTimeRange{
+			From: "now-15m",
+			To:   "now",
+		},
+		Query: &kbapi.LocatorQuery{
+			Query:    "status:500",
+			Language: kbapi.LocatorQueryLanguageKQL,
+		},
+	}
+	shortenURL, err := dashboardParams.ShortenURL()
+	if err != nil {
+		log.Fatalf("Error building shorten URL: %s", err)
 	}
 	shortenURLResponse, err := client.API.KibanaShortenURL.Create(shortenURL)
 	if err != nil {
 		log.Fatalf("Error creating shorten URL: %s", err)
 	}
-	log.Println(fmt.Sprintf("http://localhost:5601/goto/%s", shortenURLResponse.ID))
+	log.Println(shortenURLResponse.GotoURL(cfg.Address, ""))
 
 	// Create or update Logstash pipeline
 	logstashPipeline := &kbapi.LogstashPipeline{
 		ID:          "sample",
 		Description: "Sample logstash pipeline",
 		Pipeline:    "input { stdin {} } output { stdout {} }",
-		Settings: map[string]interface{}{
-			"queue.type": "persisted",
+		Settings: &kbapi.LogstashPipelineSettings{
+			QueueType: kbapi.LogstashPipelineQueuePersisted,
 		},
 	}
-	logstashPipeline, err = client.API.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline)
+	logstashPipeline, created, err := client.API.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline, nil)
 	if err != nil {
 		log.Fatalf("Error creating logstash pipeline: %s", err)
 	}
-	log.Println(logstashPipeline)
+	log.Printf("Logstash pipeline created: %t, %v", created, logstashPipeline)
 
 	// Get the logstash pipeline
 	logstashPipeline, err = client.API.KibanaLogstashPipeline.Get("sample")
@@ -105,7 +116,7 @@ func main() {
 	log.Println(space)
 
 	// Get all user space
-	spaces, err := client.API.KibanaSpaces.List()
+	spaces, err := client.API.KibanaSpaces.List(nil)
 	if err != nil {
 		log.Fatalf("Error getting all user spaces: %s", err)
 	}
@@ -123,11 +134,11 @@ func main() {
 			},
 		},
 	}
-	err = client.API.KibanaSpaces.CopySavedObjects(parameter, "")
+	copyResult, err := client.API.KibanaSpaces.CopySavedObjects(parameter, "")
 	if err != nil {
 		log.Fatalf("Error copying object from another user space: %s", err)
 	}
-	log.Println("Copying config object from 'default' to 'test' user space successfully")
+	log.Println("Copying config object from 'default' to 'test' user space successfully: ", copyResult["test"].SuccessCount)
 
 	// Delete user space
 	err = client.API.KibanaSpaces.Delete("test")
@@ -143,11 +154,11 @@ func main() {
 	}
 	data := make(map[string]interface{})
 	err = json.Unmarshal(b, &data)
-	err = client.API.KibanaDashboard.Import(data, nil, true, "default")
+	importResult, err := client.API.KibanaDashboard.Import(data, nil, true, "default")
 	if err != nil {
 		log.Fatalf("Error importing dashboard: %s", err)
 	}
-	log.Println("Importing dashboard successfully")
+	log.Printf("Importing %d objects successfully", len(importResult.Objects))
 
 	// Export dashboard from default user space
 	data, err = client.API.KibanaDashboard.Export([]string{"edf84fe0-e1a0-11e7-b6d5-4dc382ef7f5b"}, "default")
@@ -213,7 +224,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error converting json to struct: %s", err)
 	}
-	resp, err := client.API.KibanaSavedObject.Create(data, "index-pattern", "test", true, "default")
+	resp, err := client.API.KibanaSavedObject.Create(data, "index-pattern", "test", true, "default", nil)
 	if err != nil {
 		log.Fatalf("Error creating object: %s", err)
 	}
@@ -244,7 +255,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error converting json to struct")
 	}
-	resp, err = client.API.KibanaSavedObject.Update(data, "index-pattern", "test", "default")
+	resp, err = client.API.KibanaSavedObject.Update(data, "index-pattern", "test", "default", nil)
 	if err != nil {
 		log.Fatalf("Error updating index pattern: %s", err)
 	}
@@ -13,16 +13,24 @@ type API struct {
 	KibanaStatus           *KibanaStatusAPI
 	KibanaLogstashPipeline *KibanaLogstashPipelineAPI
 	KibanaShortenURL       *KibanaShortenURLAPI
+	KibanaFeatures         *KibanaFeaturesAPI
+	KibanaDataViews        *KibanaDataViewsAPI
 }
 
 // KibanaSpacesAPI handle the spaces API
 type KibanaSpacesAPI struct {
-	Get              KibanaSpaceGet
-	List             KibanaSpaceList
-	Create           KibanaSpaceCreate
-	Delete           KibanaSpaceDelete
-	Update           KibanaSpaceUpdate
-	CopySavedObjects KibanaSpaceCopySavedObjects
+	Get                           KibanaSpaceGet
+	List                          KibanaSpaceList
+	Create                        KibanaSpaceCreate
+	Delete                        KibanaSpaceDelete
+	SafeDelete                    KibanaSpaceSafeDelete
+	Update                        KibanaSpaceUpdate
+	CopySavedObjects              KibanaSpaceCopySavedObjects
+	ResolveCopySavedObjectsErrors KibanaSpaceResolveCopySavedObjectsErrors
+	UpdateObjectsSpaces           KibanaSpaceUpdateObjectsSpaces
+	GetShareableReferences        KibanaSpaceGetShareableReferences
+	DisableLegacyURLAliases       KibanaSpaceDisableLegacyURLAliases
+	FindLegacyURLAliases          KibanaSpaceFindLegacyURLAliases
 }
 
 // KibanaRoleManagementAPI handle the role management API
@@ -30,24 +38,29 @@ type KibanaRoleManagementAPI struct {
 	Get            KibanaRoleManagementGet
 	List           KibanaRoleManagementList
 	CreateOrUpdate KibanaRoleManagementCreateOrUpdate
+	Create         KibanaRoleManagementCreate
+	Update         KibanaRoleManagementUpdate
 	Delete         KibanaRoleManagementDelete
 }
 
 // KibanaDashboardAPI handle the dashboard API
 type KibanaDashboardAPI struct {
-	Export KibanaDashboardExport
-	Import KibanaDashboardImport
+	Export             KibanaDashboardExport
+	Import             KibanaDashboardImport
+	ImportSavedObjects KibanaDashboardImportSavedObjects
 }
 
 // KibanaSavedObjectAPI handle the saved object API
 type KibanaSavedObjectAPI struct {
-	Get    KibanaSavedObjectGet
-	Find   KibanaSavedObjectFind
-	Create KibanaSavedObjectCreate
-	Update KibanaSavedObjectUpdate
-	Delete KibanaSavedObjectDelete
-	Import KibanaSavedObjectImport
-	Export KibanaSavedObjectExport
+	Get          KibanaSavedObjectGet
+	Find         KibanaSavedObjectFind
+	Create       KibanaSavedObjectCreate
+	Update       KibanaSavedObjectUpdate
+	BulkUpdate   KibanaSavedObjectBulkUpdate
+	GetAndUpdate KibanaSavedObjectGetAndUpdate
+	Delete       KibanaSavedObjectDelete
+	Import       KibanaSavedObjectImport
+	Export       KibanaSavedObjectExport
 }
 
 // KibanaStatusAPI handle the status API
@@ -61,42 +74,73 @@ type KibanaLogstashPipelineAPI struct {
 	List           KibanaLogstashPipelineList
 	CreateOrUpdate KibanaLogstashPipelineCreateOrUpdate
 	Delete         KibanaLogstashPipelineDelete
+	BulkDelete     KibanaLogstashPipelineBulkDelete
 }
 
 // KibanaShortenURLAPI handle the shorten URL API
 type KibanaShortenURLAPI struct {
-	Create KibanaShortenURLCreate
+	Create  KibanaShortenURLCreate
+	Get     KibanaShortenURLGet
+	Resolve KibanaShortenURLResolve
+	Delete  KibanaShortenURLDelete
+}
+
+// KibanaFeaturesAPI handle the features API
+type KibanaFeaturesAPI struct {
+	List KibanaFeatureList
+}
+
+// KibanaDataViewsAPI handle the data views API
+type KibanaDataViewsAPI struct {
+	Get            KibanaDataViewGet
+	List           KibanaDataViewList
+	Create         KibanaDataViewCreate
+	Update         KibanaDataViewUpdate
+	Delete         KibanaDataViewDelete
+	SetDefault     KibanaDataViewSetDefault
+	SwapReferences KibanaDataViewSwapReferences
 }
 
 // New initialise the API implementation
 func New(c *resty.Client) *API {
 	return &API{
 		KibanaSpaces: &KibanaSpacesAPI{
-			Get:              newKibanaSpaceGetFunc(c),
-			List:             newKibanaSpaceListFunc(c),
-			Create:           newKibanaSpaceCreateFunc(c),
-			Update:           newKibanaSpaceUpdateFunc(c),
-			Delete:           newKibanaSpaceDeleteFunc(c),
-			CopySavedObjects: newKibanaSpaceCopySavedObjectsFunc(c),
+			Get:                           newKibanaSpaceGetFunc(c),
+			List:                          newKibanaSpaceListFunc(c),
+			Create:                        newKibanaSpaceCreateFunc(c),
+			Update:                        newKibanaSpaceUpdateFunc(c),
+			Delete:                        newKibanaSpaceDeleteFunc(c),
+			SafeDelete:                    newKibanaSpaceSafeDeleteFunc(c),
+			CopySavedObjects:              newKibanaSpaceCopySavedObjectsFunc(c),
+			ResolveCopySavedObjectsErrors: newKibanaSpaceResolveCopySavedObjectsErrorsFunc(c),
+			UpdateObjectsSpaces:           newKibanaSpaceUpdateObjectsSpacesFunc(c),
+			GetShareableReferences:        newKibanaSpaceGetShareableReferencesFunc(c),
+			DisableLegacyURLAliases:       newKibanaSpaceDisableLegacyURLAliasesFunc(c),
+			FindLegacyURLAliases:          newKibanaSpaceFindLegacyURLAliasesFunc(c),
 		},
 		KibanaRoleManagement: &KibanaRoleManagementAPI{
 			Get:            newKibanaRoleManagementGetFunc(c),
 			List:           newKibanaRoleManagementListFunc(c),
 			CreateOrUpdate: newKibanaRoleManagementCreateOrUpdateFunc(c),
+			Create:         newKibanaRoleManagementCreateFunc(c),
+			Update:         newKibanaRoleManagementUpdateFunc(c),
 			Delete:         newKibanaRoleManagementDeleteFunc(c),
 		},
 		KibanaDashboard: &KibanaDashboardAPI{
-			Export: newKibanaDashboardExportFunc(c),
-			Import: newKibanaDashboardImportFunc(c),
+			Export:             newKibanaDashboardExportFunc(c),
+			Import:             newKibanaDashboardImportFunc(c),
+			ImportSavedObjects: newKibanaDashboardImportSavedObjectsFunc(c),
 		},
 		KibanaSavedObject: &KibanaSavedObjectAPI{
-			Get:    newKibanaSavedObjectGetFunc(c),
-			Find:   newKibanaSavedObjectFindFunc(c),
-			Create: newKibanaSavedObjectCreateFunc(c),
-			Update: newKibanaSavedObjectUpdateFunc(c),
-			Delete: newKibanaSavedObjectDeleteFunc(c),
-			Import: newKibanaSavedObjectImportFunc(c),
-			Export: newKibanaSavedObjectExportFunc(c),
+			Get:          newKibanaSavedObjectGetFunc(c),
+			Find:         newKibanaSavedObjectFindFunc(c),
+			Create:       newKibanaSavedObjectCreateFunc(c),
+			Update:       newKibanaSavedObjectUpdateFunc(c),
+			BulkUpdate:   newKibanaSavedObjectBulkUpdateFunc(c),
+			GetAndUpdate: newKibanaSavedObjectGetAndUpdateFunc(c),
+			Delete:       newKibanaSavedObjectDeleteFunc(c),
+			Import:       newKibanaSavedObjectImportFunc(c),
+			Export:       newKibanaSavedObjectExportFunc(c),
 		},
 		KibanaStatus: &KibanaStatusAPI{
 			Get: newKibanaStatusGetFunc(c),
@@ -106,9 +150,25 @@ func New(c *resty.Client) *API {
 			List:           newKibanaLogstashPipelineListFunc(c),
 			CreateOrUpdate: newKibanaLogstashPipelineCreateOrUpdateFunc(c),
 			Delete:         newKibanaLogstashPipelineDeleteFunc(c),
+			BulkDelete:     newKibanaLogstashPipelineBulkDeleteFunc(c),
 		},
 		KibanaShortenURL: &KibanaShortenURLAPI{
-			Create: newKibanaShortenURLCreateFunc(c),
+			Create:  newKibanaShortenURLCreateFunc(c),
+			Get:     newKibanaShortenURLGetFunc(c),
+			Resolve: newKibanaShortenURLResolveFunc(c),
+			Delete:  newKibanaShortenURLDeleteFunc(c),
+		},
+		KibanaFeatures: &KibanaFeaturesAPI{
+			List: newKibanaFeatureListFunc(c),
+		},
+		KibanaDataViews: &KibanaDataViewsAPI{
+			Get:            newKibanaDataViewGetFunc(c),
+			List:           newKibanaDataViewListFunc(c),
+			Create:         newKibanaDataViewCreateFunc(c),
+			Update:         newKibanaDataViewUpdateFunc(c),
+			Delete:         newKibanaDataViewDeleteFunc(c),
+			SetDefault:     newKibanaDataViewSetDefaultFunc(c),
+			SwapReferences: newKibanaDataViewSwapReferencesFunc(c),
 		},
 	}
 }
@@ -1,11 +1,13 @@
 package kbapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
+
 	"github.com/go-resty/resty/v2"
 	log "github.com/sirupsen/logrus"
-	"strings"
 )
 
 const (
@@ -16,7 +18,51 @@ const (
 type KibanaDashboardExport func(listID []string, kibanaSpace string) (map[string]interface{}, error)
 
 // KibanaDashboardImport permit to import dashboard
-type KibanaDashboardImport func(data map[string]interface{}, listExcludeType []string, force bool, kibanaSpace string) error
+type KibanaDashboardImport func(data map[string]interface{}, listExcludeType []string, force bool, kibanaSpace string) (*KibanaDashboardImportResult, error)
+
+// KibanaDashboardImportSavedObjects permit to import dashboard exported by KibanaDashboardExport with the saved objects import API.
+// It's the replacement of the deprecated dashboard import API
+type KibanaDashboardImportSavedObjects func(data map[string]interface{}, listExcludeType []string, force bool, kibanaSpace string) (*KibanaDashboardImportResult, error)
+
+// KibanaDashboardImportResult is the objects imported and the objects on error
+type KibanaDashboardImportResult struct {
+	Objects []KibanaDashboardImportObject
+	Errors  []KibanaDashboardImportError
+}
+
+// KibanaDashboardImportObject is object imported
+type KibanaDashboardImportObject struct {
+	Type  string
+	ID    string
+	Title string
+}
+
+// KibanaDashboardImportError is object not imported
+type KibanaDashboardImportError struct {
+	Type       string
+	ID         string
+	Title      string
+	StatusCode int
+	Message    string
+}
+
+// HasErrors return true if some objects are not imported
+func (r *KibanaDashboardImportResult) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// err return APIError that list the objects on error, or nil
+// The request succeed, so the code is 600 like other client side errors. The status of each object is on Errors
+func (r *KibanaDashboardImportResult) err() error {
+	if !r.HasErrors() {
+		return nil
+	}
+	messages := make([]string, 0, len(r.Errors))
+	for _, importError := range r.Errors {
+		messages = append(messages, fmt.Sprintf("%s/%s: %s", importError.Type, importError.ID, importError.Message))
+	}
+	return NewAPIError(600, "Error when import %d objects: %s", len(r.Errors), strings.Join(messages, ", "))
+}
 
 // newKibanaDashboardExportFunc permit to export Kibana dashboard by its names
 func newKibanaDashboardExportFunc(c *resty.Client) KibanaDashboardExport {
@@ -63,11 +109,12 @@ func newKibanaDashboardExportFunc(c *resty.Client) KibanaDashboardExport {
 }
 
 // newKibanaDashboardImportFunc permit to import kibana dashboard
+// It return the result and an error when some objects are not imported
 func newKibanaDashboardImportFunc(c *resty.Client) KibanaDashboardImport {
-	return func(data map[string]interface{}, listExcludeType []string, force bool, kibanaSpace string) error {
+	return func(data map[string]interface{}, listExcludeType []string, force bool, kibanaSpace string) (*KibanaDashboardImportResult, error) {
 
 		if data == nil {
-			return NewAPIError(600, "You must provide one or more dashboard to import")
+			return nil, NewAPIError(600, "You must provide one or more dashboard to import")
 		}
 		log.Debug("data: ", data)
 		log.Debug("List type to exclude: ", listExcludeType)
@@ -83,32 +130,168 @@ func newKibanaDashboardImportFunc(c *resty.Client) KibanaDashboardImport {
 
 		log.Debugf("URL to import %s", path)
 
-		request := c.R().SetQueryString(fmt.Sprintf("force=%t", force))
+		request := c.R().SetQueryParam("force", fmt.Sprintf("%t", force))
 		if len(listExcludeType) > 0 {
-			request = request.SetQueryString(fmt.Sprintf("exclude=%s", strings.Join(listExcludeType, ",")))
+			request.SetQueryParam("exclude", strings.Join(listExcludeType, ","))
 		}
 		jsonData, err := json.Marshal(data)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		resp, err := request.SetBody(jsonData).Post(path)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		log.Debug("Response: ", resp)
 		if resp.StatusCode() >= 300 {
-			return NewAPIError(resp.StatusCode(), resp.Status())
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
 		}
-		var dataResponse map[string]interface{}
+		dataResponse := struct {
+			Objects []struct {
+				Type       string `json:"type"`
+				ID         string `json:"id"`
+				Attributes struct {
+					Title string `json:"title"`
+				} `json:"attributes"`
+				Error *struct {
+					StatusCode int    `json:"statusCode"`
+					Message    string `json:"message"`
+				} `json:"error"`
+			} `json:"objects"`
+		}{}
 		err = json.Unmarshal(resp.Body(), &dataResponse)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		log.Debug("Data response: ", dataResponse)
 
-		// Need to manage error returned in response
+		result := &KibanaDashboardImportResult{
+			Objects: make([]KibanaDashboardImportObject, 0, len(dataResponse.Objects)),
+		}
+		for _, object := range dataResponse.Objects {
+			if object.Error != nil {
+				result.Errors = append(result.Errors, KibanaDashboardImportError{
+					Type:       object.Type,
+					ID:         object.ID,
+					Title:      object.Attributes.Title,
+					StatusCode: object.Error.StatusCode,
+					Message:    object.Error.Message,
+				})
+				continue
+			}
+			result.Objects = append(result.Objects, KibanaDashboardImportObject{
+				Type:  object.Type,
+				ID:    object.ID,
+				Title: object.Attributes.Title,
+			})
+		}
 
-		return nil
+		return result, result.err()
 	}
 
 }
+
+// newKibanaDashboardImportSavedObjectsFunc permit to import kibana dashboard with saved objects import API
+// The objects are converted to ndjson, and force overwrite the existing objects.
+// It return the result and an error when some objects are not imported
+func newKibanaDashboardImportSavedObjectsFunc(c *resty.Client) KibanaDashboardImportSavedObjects {
+	return func(data map[string]interface{}, listExcludeType []string, force bool, kibanaSpace string) (*KibanaDashboardImportResult, error) {
+
+		if data == nil {
+			return nil, NewAPIError(600, "You must provide one or more dashboard to import")
+		}
+		log.Debug("data: ", data)
+		log.Debug("List type to exclude: ", listExcludeType)
+		log.Debug("Force import: ", force)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		objects, ok := data["objects"].([]interface{})
+		if !ok {
+			return nil, NewAPIError(600, "Data must contain objects like the dashboard export")
+		}
+		ndjson := &bytes.Buffer{}
+		for _, object := range objects {
+			if objectMap, ok := object.(map[string]interface{}); ok {
+				if objectType, _ := objectMap["type"].(string); stringInSlice(objectType, listExcludeType) {
+					continue
+				}
+			}
+			line, err := json.Marshal(object)
+			if err != nil {
+				return nil, err
+			}
+			ndjson.Write(line)
+			ndjson.WriteByte('\n')
+		}
+		if ndjson.Len() == 0 {
+			return nil, NewAPIError(600, "No object to import")
+		}
+
+		resp, err := newKibanaSavedObjectImportFunc(c)(ndjson.Bytes(), force, kibanaSpace)
+		if err != nil {
+			return nil, err
+		}
+
+		dataResponse := struct {
+			SuccessResults []struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+				Meta struct {
+					Title string `json:"title"`
+				} `json:"meta"`
+			} `json:"successResults"`
+			Errors []struct {
+				Type  string `json:"type"`
+				ID    string `json:"id"`
+				Title string `json:"title"`
+				Meta  struct {
+					Title string `json:"title"`
+				} `json:"meta"`
+				Error struct {
+					Type       string `json:"type"`
+					StatusCode int    `json:"statusCode"`
+					Message    string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		}{}
+		b, err := json.Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, &dataResponse); err != nil {
+			return nil, err
+		}
+
+		result := &KibanaDashboardImportResult{
+			Objects: make([]KibanaDashboardImportObject, 0, len(dataResponse.SuccessResults)),
+		}
+		for _, object := range dataResponse.SuccessResults {
+			result.Objects = append(result.Objects, KibanaDashboardImportObject{
+				Type:  object.Type,
+				ID:    object.ID,
+				Title: object.Meta.Title,
+			})
+		}
+		for _, object := range dataResponse.Errors {
+			importError := KibanaDashboardImportError{
+				Type:       object.Type,
+				ID:         object.ID,
+				Title:      object.Title,
+				StatusCode: object.Error.StatusCode,
+				Message:    object.Error.Message,
+			}
+			if importError.Title == "" {
+				importError.Title = object.Meta.Title
+			}
+			if importError.Message == "" {
+				importError.Message = object.Error.Type
+			}
+			if importError.StatusCode == 0 && object.Error.Type == "conflict" {
+				importError.StatusCode = 409
+			}
+			result.Errors = append(result.Errors, importError)
+		}
+
+		return result, result.err()
+	}
+}
@@ -0,0 +1,236 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DashboardGridColumns is the number of columns of dashboard grid
+const DashboardGridColumns = 48
+
+// Type of objects displayed by dashboard panels
+const (
+	DashboardPanelTypeVisualization = "visualization"
+	DashboardPanelTypeLens          = "lens"
+	DashboardPanelTypeSearch        = "search"
+	DashboardPanelTypeMap           = "map"
+)
+
+// DashboardBuilder permit to build dashboard from code.
+// Panels added with AddPanel flow from left to right and go to the next row when the row is full.
+// The first error is returned by Build.
+type DashboardBuilder struct {
+	dashboard *Dashboard
+	x         int
+	y         int
+	rowHeight int
+	bottom    int
+	err       error
+}
+
+// NewDashboardBuilder return builder of dashboard with ID and title
+func NewDashboardBuilder(id string, title string) *DashboardBuilder {
+	b := &DashboardBuilder{
+		dashboard: &Dashboard{
+			ID:     id,
+			Title:  title,
+			Panels: make([]DashboardPanel, 0),
+			Options: DashboardOptions{
+				UseMargins: true,
+			},
+			SearchSource: DashboardSearchSource{
+				Query: &LocatorQuery{
+					Query:    "",
+					Language: LocatorQueryLanguageKQL,
+				},
+				Filter: make([]map[string]any, 0),
+			},
+			References: make([]KibanaSavedObjectReference, 0),
+		},
+	}
+	if title == "" {
+		b.err = NewAPIError(600, "You must provide the dashboard title")
+	}
+	return b
+}
+
+// Description set the dashboard description
+func (b *DashboardBuilder) Description(description string) *DashboardBuilder {
+	b.dashboard.Description = description
+	return b
+}
+
+// Query set the query of dashboard search bar
+func (b *DashboardBuilder) Query(query *LocatorQuery) *DashboardBuilder {
+	if b.err == nil && query != nil && query.Language != LocatorQueryLanguageKQL && query.Language != LocatorQueryLanguageLucene {
+		b.err = NewAPIError(600, "Query language must be %s or %s", LocatorQueryLanguageKQL, LocatorQueryLanguageLucene)
+	}
+	b.dashboard.SearchSource.Query = query
+	return b
+}
+
+// TimeRestore save the time range with the dashboard, like now-15m to now
+func (b *DashboardBuilder) TimeRestore(from string, to string) *DashboardBuilder {
+	if b.err == nil && (from == "" || to == "") {
+		b.err = NewAPIError(600, "Time range must have from and to")
+	}
+	b.dashboard.TimeRestore = true
+	b.dashboard.TimeFrom = from
+	b.dashboard.TimeTo = to
+	return b
+}
+
+// RefreshInterval save the auto refresh with the dashboard. Zero interval pause the auto refresh
+// It must be used with TimeRestore
+func (b *DashboardBuilder) RefreshInterval(interval time.Duration) *DashboardBuilder {
+	if b.err == nil && interval < 0 {
+		b.err = NewAPIError(600, "Refresh interval must be positive")
+	}
+	b.dashboard.RefreshInterval = &LocatorRefreshInterval{
+		Pause: interval == 0,
+		Value: interval.Milliseconds(),
+	}
+	return b
+}
+
+// HidePanelTitles hide the title of all panels
+func (b *DashboardBuilder) HidePanelTitles(hide bool) *DashboardBuilder {
+	b.dashboard.Options.HidePanelTitles = hide
+	return b
+}
+
+// AddPanel add panel that display the existing object, after the previous panel.
+// The panel go to the next row when it not fit on the current row. title can be empty to use the object title
+func (b *DashboardBuilder) AddPanel(objectType string, id string, width int, height int, title string) *DashboardBuilder {
+	if b.err != nil {
+		return b
+	}
+	if width < 1 || width > DashboardGridColumns || height < 1 {
+		b.err = NewAPIError(600, "Panel size %dx%d must be between 1 and %d columns and at least 1 row", width, height, DashboardGridColumns)
+		return b
+	}
+	if b.x+width > DashboardGridColumns {
+		b.NewRow()
+	}
+	b.addPanel(objectType, id, b.x, b.y, width, height, title)
+	b.x += width
+	if height > b.rowHeight {
+		b.rowHeight = height
+	}
+	return b
+}
+
+// AddPanelAt add panel that display the existing object at fixed position.
+// It fail if the panel overlap another panel. The next panels added with AddPanel start on new row under all panels
+func (b *DashboardBuilder) AddPanelAt(objectType string, id string, x int, y int, width int, height int, title string) *DashboardBuilder {
+	if b.err != nil {
+		return b
+	}
+	if x < 0 || y < 0 || width < 1 || height < 1 || x+width > DashboardGridColumns {
+		b.err = NewAPIError(600, "Panel at %d,%d with size %dx%d must be inside the %d columns grid", x, y, width, height, DashboardGridColumns)
+		return b
+	}
+	for _, panel := range b.dashboard.Panels {
+		gridData := panel.GridData
+		if x < gridData.X+gridData.W && gridData.X < x+width && y < gridData.Y+gridData.H && gridData.Y < y+height {
+			b.err = NewAPIError(600, "Panel at %d,%d with size %dx%d overlap panel %s", x, y, width, height, panel.PanelIndex)
+			return b
+		}
+	}
+	b.addPanel(objectType, id, x, y, width, height, title)
+	b.NewRow()
+	return b
+}
+
+// NewRow start new row under all panels
+func (b *DashboardBuilder) NewRow() *DashboardBuilder {
+	b.x = 0
+	b.y = b.bottom
+	b.rowHeight = 0
+	return b
+}
+
+// Build return the dashboard
+func (b *DashboardBuilder) Build() (*Dashboard, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.dashboard.RefreshInterval != nil && !b.dashboard.TimeRestore {
+		return nil, NewAPIError(600, "Refresh interval need time restore")
+	}
+	dashboard := *b.dashboard
+	return &dashboard, nil
+}
+
+// addPanel add the panel and its reference
+func (b *DashboardBuilder) addPanel(objectType string, id string, x int, y int, width int, height int, title string) {
+	if objectType == "" || id == "" {
+		b.err = NewAPIError(600, "You must provide the type and ID of panel object")
+		return
+	}
+
+	panelIndex := strconv.Itoa(len(b.dashboard.Panels) + 1)
+	panelRefName := fmt.Sprintf("panel_%s", panelIndex)
+	embeddableConfig := map[string]any{}
+	if title != "" {
+		embeddableConfig["title"] = title
+	}
+	embeddableConfigJSON, err := json.Marshal(embeddableConfig)
+	if err != nil {
+		b.err = err
+		return
+	}
+
+	b.dashboard.Panels = append(b.dashboard.Panels, DashboardPanel{
+		PanelIndex: panelIndex,
+		GridData: DashboardGridData{
+			X: x,
+			Y: y,
+			W: width,
+			H: height,
+			I: panelIndex,
+		},
+		Type:             objectType,
+		EmbeddableConfig: embeddableConfigJSON,
+		PanelRefName:     panelRefName,
+	})
+	b.dashboard.References = append(b.dashboard.References, KibanaSavedObjectReference{
+		Name: fmt.Sprintf("%s:%s", panelIndex, panelRefName),
+		Type: objectType,
+		ID:   id,
+	})
+	if y+height > b.bottom {
+		b.bottom = y + height
+	}
+}
+
+// CreatePayload return the data to create the dashboard with KibanaSavedObject.Create
+func (d *Dashboard) CreatePayload() (map[string]interface{}, error) {
+	attributes, err := d.Attributes()
+	if err != nil {
+		return nil, err
+	}
+	references := d.References
+	if references == nil {
+		references = make([]KibanaSavedObjectReference, 0)
+	}
+
+	return map[string]interface{}{
+		"attributes": attributes,
+		"references": references,
+	}, nil
+}
+
+// NDJSON return the dashboard as ndjson line, to import it with KibanaSavedObject.Import
+func (d *Dashboard) NDJSON() ([]byte, error) {
+	if d.ID == "" {
+		return nil, NewAPIError(600, "You must provide the dashboard ID to import it")
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,88 @@
+package kbapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboardBuilder(t *testing.T) {
+
+	dashboard, err := NewDashboardBuilder("service-api", "Service api").
+		Description("Generated dashboard").
+		TimeRestore("now-24h", "now").
+		RefreshInterval(time.Minute).
+		AddPanel(DashboardPanelTypeLens, "latency", 24, 15, "Latency").
+		AddPanel(DashboardPanelTypeLens, "errors", 24, 10, "").
+		AddPanel(DashboardPanelTypeVisualization, "throughput", 16, 8, "").
+		AddPanel(DashboardPanelTypeVisualization, "cpu", 16, 8, "").
+		NewRow().
+		AddPanel(DashboardPanelTypeSearch, "logs", 48, 20, "Logs").
+		AddPanelAt(DashboardPanelTypeVisualization, "legend", 40, 15, 8, 5, "").
+		AddPanel(DashboardPanelTypeVisualization, "footer", 48, 5, "").
+		Build()
+	assert.NoError(t, err)
+
+	gridData := make([]DashboardGridData, 0, len(dashboard.Panels))
+	for _, panel := range dashboard.Panels {
+		gridData = append(gridData, panel.GridData)
+	}
+	assert.Equal(t, []DashboardGridData{
+		{X: 0, Y: 0, W: 24, H: 15, I: "1"},
+		{X: 24, Y: 0, W: 24, H: 10, I: "2"},
+		{X: 0, Y: 15, W: 16, H: 8, I: "3"},
+		{X: 16, Y: 15, W: 16, H: 8, I: "4"},
+		{X: 0, Y: 23, W: 48, H: 20, I: "5"},
+		{X: 40, Y: 15, W: 8, H: 5, I: "6"},
+		{X: 0, Y: 43, W: 48, H: 5, I: "7"},
+	}, gridData)
+	assert.JSONEq(t, `{"title":"Latency"}`, string(dashboard.Panels[0].EmbeddableConfig))
+	assert.JSONEq(t, `{}`, string(dashboard.Panels[1].EmbeddableConfig))
+	assert.Equal(t, KibanaSavedObjectReference{Name: "1:panel_1", Type: DashboardPanelTypeLens, ID: "latency"}, dashboard.References[0])
+	assert.Equal(t, "logs", dashboard.PanelReference(&dashboard.Panels[4]).ID)
+	assert.NoError(t, dashboard.ValidateReferences())
+
+	// Create payload
+	payload, err := dashboard.CreatePayload()
+	assert.NoError(t, err)
+	attributes := payload["attributes"].(map[string]interface{})
+	assert.Equal(t, "Service api", attributes["title"])
+	assert.Equal(t, true, attributes["timeRestore"])
+	assert.Equal(t, "now-24h", attributes["timeFrom"])
+	assert.Equal(t, map[string]interface{}{"pause": false, "value": float64(60000)}, attributes["refreshInterval"])
+	assert.JSONEq(t, `{"hidePanelTitles":false,"useMargins":true}`, attributes["optionsJSON"].(string))
+	assert.JSONEq(t, `{"query":{"query":"","language":"kuery"},"filter":[]}`, attributes["kibanaSavedObjectMeta"].(map[string]interface{})["searchSourceJSON"].(string))
+	assert.Len(t, payload["references"], 7)
+
+	// ndjson
+	ndjson, err := dashboard.NDJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(ndjson, []byte("\n")))
+	imported := &Dashboard{}
+	assert.NoError(t, json.Unmarshal(ndjson, imported))
+	assert.Equal(t, "service-api", imported.ID)
+	assert.Equal(t, dashboard.Panels[6].GridData, imported.Panels[6].GridData)
+
+	// Invalid dashboards
+	_, err = NewDashboardBuilder("test", "").Build()
+	assert.Error(t, err)
+	_, err = NewDashboardBuilder("test", "test").AddPanel(DashboardPanelTypeLens, "test", 49, 10, "").Build()
+	assert.Error(t, err)
+	_, err = NewDashboardBuilder("test", "test").AddPanelAt(DashboardPanelTypeLens, "test", 40, 0, 10, 10, "").Build()
+	assert.Error(t, err)
+	_, err = NewDashboardBuilder("test", "test").
+		AddPanel(DashboardPanelTypeLens, "latency", 24, 15, "").
+		AddPanel(DashboardPanelTypeLens, "errors", 24, 10, "").
+		AddPanelAt(DashboardPanelTypeVisualization, "legend", 40, 0, 8, 5, "").
+		Build()
+	assert.EqualError(t, err, "Panel at 40,0 with size 8x5 overlap panel 2")
+	_, err = NewDashboardBuilder("test", "test").AddPanel(DashboardPanelTypeLens, "", 10, 10, "").Build()
+	assert.Error(t, err)
+	_, err = NewDashboardBuilder("test", "test").RefreshInterval(time.Minute).Build()
+	assert.Error(t, err)
+	_, err = (&Dashboard{}).NDJSON()
+	assert.Error(t, err)
+}
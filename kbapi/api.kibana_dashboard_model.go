@@ -0,0 +1,403 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Dashboard is the typed dashboard saved object.
+// The panelsJSON, optionsJSON and kibanaSavedObjectMeta.searchSourceJSON attributes, stored by Kibana as JSON strings,
+// are decoded to Panels, Options and SearchSource. Unknown fields are kept on Extra to not lose them on update.
+type Dashboard struct {
+	ID              string
+	Title           string
+	Description     string
+	Panels          []DashboardPanel
+	Options         DashboardOptions
+	SearchSource    DashboardSearchSource
+	TimeRestore     bool
+	TimeFrom        string
+	TimeTo          string
+	RefreshInterval *LocatorRefreshInterval
+	References      []KibanaSavedObjectReference
+
+	// AttributesExtra is the unknown attributes, like hits
+	AttributesExtra map[string]json.RawMessage
+
+	// Extra is the unknown saved object fields, like version, updated_at or migrationVersion
+	Extra map[string]json.RawMessage
+}
+
+// DashboardPanel is a panel of dashboard. EmbeddableConfig is kept as raw JSON because it depend of the panel type.
+// The type and ID of the panel object are on the reference named PanelRefName, or on Type and ID for old dashboards
+type DashboardPanel struct {
+	PanelIndex       string                     `json:"panelIndex"`
+	GridData         DashboardGridData          `json:"gridData"`
+	Type             string                     `json:"type,omitempty"`
+	ID               string                     `json:"id,omitempty"`
+	EmbeddableConfig json.RawMessage            `json:"embeddableConfig,omitempty"`
+	PanelRefName     string                     `json:"panelRefName,omitempty"`
+	Version          string                     `json:"version,omitempty"`
+	Extra            map[string]json.RawMessage `json:"-"`
+}
+
+// DashboardGridData is the panel position, on 48 columns grid
+type DashboardGridData struct {
+	X int    `json:"x"`
+	Y int    `json:"y"`
+	W int    `json:"w"`
+	H int    `json:"h"`
+	I string `json:"i"`
+}
+
+// DashboardOptions is the dashboard options
+type DashboardOptions struct {
+	HidePanelTitles bool                       `json:"hidePanelTitles"`
+	UseMargins      bool                       `json:"useMargins"`
+	Extra           map[string]json.RawMessage `json:"-"`
+}
+
+// DashboardSearchSource is the query and filters saved with dashboard
+type DashboardSearchSource struct {
+	Query  *LocatorQuery              `json:"query,omitempty"`
+	Filter []map[string]any           `json:"filter"`
+	Extra  map[string]json.RawMessage `json:"-"`
+}
+
+// dashboardPanelJSON, dashboardOptionsJSON and dashboardSearchSourceJSON are used to marshal / unmarshal without recursion
+type dashboardPanelJSON DashboardPanel
+type dashboardOptionsJSON DashboardOptions
+type dashboardSearchSourceJSON DashboardSearchSource
+
+// dashboardSavedObject is the dashboard as saved object
+type dashboardSavedObject struct {
+	ID         string                       `json:"id,omitempty"`
+	Type       string                       `json:"type"`
+	Attributes dashboardAttributes          `json:"attributes"`
+	References []KibanaSavedObjectReference `json:"references"`
+}
+
+// dashboardAttributes is the dashboard attributes, as stored by Kibana
+type dashboardAttributes struct {
+	Title                 string                   `json:"title"`
+	Description           string                   `json:"description"`
+	PanelsJSON            string                   `json:"panelsJSON"`
+	OptionsJSON           string                   `json:"optionsJSON"`
+	TimeRestore           bool                     `json:"timeRestore"`
+	TimeFrom              string                   `json:"timeFrom,omitempty"`
+	TimeTo                string                   `json:"timeTo,omitempty"`
+	RefreshInterval       *LocatorRefreshInterval  `json:"refreshInterval,omitempty"`
+	KibanaSavedObjectMeta dashboardSavedObjectMeta `json:"kibanaSavedObjectMeta"`
+}
+
+type dashboardSavedObjectMeta struct {
+	SearchSourceJSON string `json:"searchSourceJSON"`
+}
+
+var (
+	dashboardFields             = []string{"id", "type", "attributes", "references"}
+	dashboardAttributesFields   = []string{"title", "description", "panelsJSON", "optionsJSON", "timeRestore", "timeFrom", "timeTo", "refreshInterval", "kibanaSavedObjectMeta"}
+	dashboardPanelFields        = []string{"panelIndex", "gridData", "type", "id", "embeddableConfig", "panelRefName", "version"}
+	dashboardOptionsFields      = []string{"hidePanelTitles", "useMargins"}
+	dashboardSearchSourceFields = []string{"query", "filter"}
+)
+
+// NewDashboardFromSavedObject return the dashboard from the saved object returned by KibanaSavedObject.Get
+func NewDashboardFromSavedObject(savedObject map[string]interface{}) (*Dashboard, error) {
+	if savedObject == nil {
+		return nil, NewAPIError(600, "You must provide the dashboard saved object")
+	}
+	if objectType, ok := savedObject["type"]; ok && objectType != "dashboard" {
+		return nil, NewAPIError(600, "Saved object type %v is not dashboard", objectType)
+	}
+
+	data, err := json.Marshal(savedObject)
+	if err != nil {
+		return nil, err
+	}
+	dashboard := &Dashboard{}
+	if err = json.Unmarshal(data, dashboard); err != nil {
+		return nil, err
+	}
+
+	return dashboard, nil
+}
+
+// Attributes return the dashboard attributes with the embedded JSON strings, to create or update it
+func (d *Dashboard) Attributes() (map[string]interface{}, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	savedObject := struct {
+		Attributes map[string]interface{} `json:"attributes"`
+	}{}
+	if err = json.Unmarshal(data, &savedObject); err != nil {
+		return nil, err
+	}
+
+	return savedObject.Attributes, nil
+}
+
+// PanelReference return the reference of the object displayed by the panel.
+// It return nil if the panel has no object or if the reference is missing
+func (d *Dashboard) PanelReference(panel *DashboardPanel) *KibanaSavedObjectReference {
+	if panel.PanelRefName == "" {
+		if panel.ID == "" {
+			return nil
+		}
+		return &KibanaSavedObjectReference{
+			Type: panel.Type,
+			ID:   panel.ID,
+		}
+	}
+	// Since Kibana 8, the reference name is prefixed by the panel index
+	for _, name := range []string{fmt.Sprintf("%s:%s", panel.PanelIndex, panel.PanelRefName), panel.PanelRefName} {
+		for i := range d.References {
+			if d.References[i].Name == name {
+				return &d.References[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateReferences return error if a panel reference is missing
+func (d *Dashboard) ValidateReferences() error {
+	for i := range d.Panels {
+		panel := &d.Panels[i]
+		if panel.PanelRefName != "" && d.PanelReference(panel) == nil {
+			return NewAPIError(600, "Reference %s of panel %s not found on dashboard %s", panel.PanelRefName, panel.PanelIndex, d.ID)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON return the dashboard as saved object, with the embedded JSON strings
+func (d Dashboard) MarshalJSON() ([]byte, error) {
+	panels := d.Panels
+	if panels == nil {
+		panels = make([]DashboardPanel, 0)
+	}
+	panelsJSON, err := json.Marshal(panels)
+	if err != nil {
+		return nil, err
+	}
+	optionsJSON, err := json.Marshal(d.Options)
+	if err != nil {
+		return nil, err
+	}
+	searchSourceJSON, err := json.Marshal(d.SearchSource)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := json.Marshal(dashboardAttributes{
+		Title:           d.Title,
+		Description:     d.Description,
+		PanelsJSON:      string(panelsJSON),
+		OptionsJSON:     string(optionsJSON),
+		TimeRestore:     d.TimeRestore,
+		TimeFrom:        d.TimeFrom,
+		TimeTo:          d.TimeTo,
+		RefreshInterval: d.RefreshInterval,
+		KibanaSavedObjectMeta: dashboardSavedObjectMeta{
+			SearchSourceJSON: string(searchSourceJSON),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if attributes, err = mergeExtraFields(attributes, d.AttributesExtra); err != nil {
+		return nil, err
+	}
+
+	references := d.References
+	if references == nil {
+		references = make([]KibanaSavedObjectReference, 0)
+	}
+	data, err := json.Marshal(struct {
+		ID         string                       `json:"id,omitempty"`
+		Type       string                       `json:"type"`
+		Attributes json.RawMessage              `json:"attributes"`
+		References []KibanaSavedObjectReference `json:"references"`
+	}{
+		ID:         d.ID,
+		Type:       "dashboard",
+		Attributes: attributes,
+		References: references,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeExtraFields(data, d.Extra)
+}
+
+// UnmarshalJSON decode the dashboard saved object and its embedded JSON strings
+func (d *Dashboard) UnmarshalJSON(data []byte) error {
+	savedObject := &dashboardSavedObject{}
+	if err := json.Unmarshal(data, savedObject); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, dashboardFields)
+	if err != nil {
+		return err
+	}
+	attributesData := struct {
+		Attributes json.RawMessage `json:"attributes"`
+	}{}
+	if err = json.Unmarshal(data, &attributesData); err != nil {
+		return err
+	}
+	var attributesExtra map[string]json.RawMessage
+	if len(attributesData.Attributes) > 0 {
+		if attributesExtra, err = extraFields(attributesData.Attributes, dashboardAttributesFields); err != nil {
+			return err
+		}
+	}
+
+	attributes := &savedObject.Attributes
+	dashboard := Dashboard{
+		ID:              savedObject.ID,
+		Title:           attributes.Title,
+		Description:     attributes.Description,
+		TimeRestore:     attributes.TimeRestore,
+		TimeFrom:        attributes.TimeFrom,
+		TimeTo:          attributes.TimeTo,
+		RefreshInterval: attributes.RefreshInterval,
+		References:      savedObject.References,
+		AttributesExtra: attributesExtra,
+		Extra:           extra,
+	}
+	if attributes.PanelsJSON != "" {
+		if err = json.Unmarshal([]byte(attributes.PanelsJSON), &dashboard.Panels); err != nil {
+			return NewAPIError(600, "Invalid panelsJSON on dashboard %s: %s", savedObject.ID, err)
+		}
+	}
+	if attributes.OptionsJSON != "" {
+		if err = json.Unmarshal([]byte(attributes.OptionsJSON), &dashboard.Options); err != nil {
+			return NewAPIError(600, "Invalid optionsJSON on dashboard %s: %s", savedObject.ID, err)
+		}
+	}
+	if attributes.KibanaSavedObjectMeta.SearchSourceJSON != "" {
+		if err = json.Unmarshal([]byte(attributes.KibanaSavedObjectMeta.SearchSourceJSON), &dashboard.SearchSource); err != nil {
+			return NewAPIError(600, "Invalid searchSourceJSON on dashboard %s: %s", savedObject.ID, err)
+		}
+	}
+
+	*d = dashboard
+	return nil
+}
+
+// MarshalJSON permit to add the unknown fields
+func (p DashboardPanel) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(dashboardPanelJSON(p))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtraFields(data, p.Extra)
+}
+
+// UnmarshalJSON permit to keep unknown fields on Extra
+func (p *DashboardPanel) UnmarshalJSON(data []byte) error {
+	panel := dashboardPanelJSON{}
+	if err := json.Unmarshal(data, &panel); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, dashboardPanelFields)
+	if err != nil {
+		return err
+	}
+	panel.Extra = extra
+
+	*p = DashboardPanel(panel)
+	return nil
+}
+
+// MarshalJSON permit to add the unknown fields
+func (o DashboardOptions) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(dashboardOptionsJSON(o))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtraFields(data, o.Extra)
+}
+
+// UnmarshalJSON permit to keep unknown fields on Extra
+func (o *DashboardOptions) UnmarshalJSON(data []byte) error {
+	options := dashboardOptionsJSON{}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, dashboardOptionsFields)
+	if err != nil {
+		return err
+	}
+	options.Extra = extra
+
+	*o = DashboardOptions(options)
+	return nil
+}
+
+// MarshalJSON permit to add the unknown fields
+func (s DashboardSearchSource) MarshalJSON() ([]byte, error) {
+	if s.Filter == nil {
+		s.Filter = make([]map[string]any, 0)
+	}
+	data, err := json.Marshal(dashboardSearchSourceJSON(s))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtraFields(data, s.Extra)
+}
+
+// UnmarshalJSON permit to keep unknown fields on Extra
+func (s *DashboardSearchSource) UnmarshalJSON(data []byte) error {
+	searchSource := dashboardSearchSourceJSON{}
+	if err := json.Unmarshal(data, &searchSource); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, dashboardSearchSourceFields)
+	if err != nil {
+		return err
+	}
+	searchSource.Extra = extra
+
+	*s = DashboardSearchSource(searchSource)
+	return nil
+}
+
+// extraFields return the fields of JSON object not in the known fields, or nil if there are none
+func extraFields(data []byte, knownFields []string) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range knownFields {
+		delete(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// mergeExtraFields add the extra fields on JSON object, without override the existing fields
+func mergeExtraFields(data []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,103 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeEmbeddedJSON replace the JSON strings of dashboard attributes by their values, to compare them
+func decodeEmbeddedJSON(t *testing.T, data []byte) map[string]any {
+	savedObject := map[string]any{}
+	if err := json.Unmarshal(data, &savedObject); err != nil {
+		t.Fatal(err)
+	}
+	attributes := savedObject["attributes"].(map[string]any)
+	for _, key := range []string{"panelsJSON", "optionsJSON"} {
+		var value any
+		if err := json.Unmarshal([]byte(attributes[key].(string)), &value); err != nil {
+			t.Fatal(err)
+		}
+		attributes[key] = value
+	}
+	meta := attributes["kibanaSavedObjectMeta"].(map[string]any)
+	var searchSource any
+	if err := json.Unmarshal([]byte(meta["searchSourceJSON"].(string)), &searchSource); err != nil {
+		t.Fatal(err)
+	}
+	meta["searchSourceJSON"] = searchSource
+
+	return savedObject
+}
+
+func TestDashboardRoundTrip(t *testing.T) {
+
+	b, err := os.ReadFile("../fixtures/kibana-dashboard.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := struct {
+		Objects []json.RawMessage `json:"objects"`
+	}{}
+	if err = json.Unmarshal(b, &fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decode the embedded JSON
+	dashboard := &Dashboard{}
+	err = json.Unmarshal(fixture.Objects[0], dashboard)
+	assert.NoError(t, err)
+	assert.Equal(t, "edf84fe0-e1a0-11e7-b6d5-4dc382ef7f5b", dashboard.ID)
+	assert.Equal(t, "[Logs] Web Traffic", dashboard.Title)
+	assert.True(t, dashboard.TimeRestore)
+	assert.Equal(t, "now-7d", dashboard.TimeFrom)
+	assert.Equal(t, int64(900000), dashboard.RefreshInterval.Value)
+	assert.Len(t, dashboard.Panels, 11)
+	assert.Equal(t, DashboardGridData{X: 27, Y: 11, W: 21, H: 13, I: "2"}, dashboard.Panels[0].GridData)
+	assert.Equal(t, "2", dashboard.Panels[0].PanelIndex)
+	assert.Equal(t, "panel_0", dashboard.Panels[0].PanelRefName)
+	assert.JSONEq(t, `{"vis":{"colors":{"Avg. Bytes":"#6ED0E0","Unique Visitors":"#0A437C"},"legendOpen":false}}`, string(dashboard.Panels[0].EmbeddableConfig))
+	assert.True(t, dashboard.Options.UseMargins)
+	assert.Equal(t, &LocatorQuery{Query: "", Language: LocatorQueryLanguageKQL}, dashboard.SearchSource.Query)
+	assert.Contains(t, dashboard.SearchSource.Extra, "highlightAll")
+	assert.Contains(t, dashboard.AttributesExtra, "hits")
+	assert.Contains(t, dashboard.Extra, "migrationVersion")
+
+	// Resolve panel references
+	assert.NoError(t, dashboard.ValidateReferences())
+	assert.Equal(t, &KibanaSavedObjectReference{Name: "panel_0", Type: "visualization", ID: "e1d0f010-9ee7-11e7-8711-e7a007dcef99"}, dashboard.PanelReference(&dashboard.Panels[0]))
+	assert.Equal(t, "47f2c680-a6e3-11e8-94b4-c30c0228351b", dashboard.PanelReference(&dashboard.Panels[10]).ID)
+	assert.Equal(t, &KibanaSavedObjectReference{Type: "visualization", ID: "123"}, dashboard.PanelReference(&DashboardPanel{Type: "visualization", ID: "123"}))
+	assert.Nil(t, dashboard.PanelReference(&DashboardPanel{PanelRefName: "panel_99"}))
+
+	// Encode without loss
+	data, err := json.Marshal(dashboard)
+	assert.NoError(t, err)
+	assert.Equal(t, decodeEmbeddedJSON(t, fixture.Objects[0]), decodeEmbeddedJSON(t, data))
+
+	// From saved object API
+	savedObject := map[string]interface{}{}
+	if err = json.Unmarshal(fixture.Objects[0], &savedObject); err != nil {
+		t.Fatal(err)
+	}
+	dashboard, err = NewDashboardFromSavedObject(savedObject)
+	assert.NoError(t, err)
+	attributes, err := dashboard.Attributes()
+	assert.NoError(t, err)
+	assert.Equal(t, savedObject["attributes"].(map[string]interface{})["optionsJSON"], attributes["optionsJSON"])
+	assert.Equal(t, float64(0), attributes["hits"])
+
+	// Missing reference
+	dashboard.References = dashboard.References[1:]
+	assert.Error(t, dashboard.ValidateReferences())
+
+	// Not a dashboard
+	_, err = NewDashboardFromSavedObject(map[string]interface{}{"type": "visualization"})
+	assert.Error(t, err)
+
+	// Invalid embedded JSON
+	err = json.Unmarshal([]byte(`{"id":"test","type":"dashboard","attributes":{"panelsJSON":"[{"}}`), &Dashboard{})
+	assert.Error(t, err)
+}
@@ -2,8 +2,14 @@ package kbapi
 
 import (
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"testing"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,8 +24,10 @@ func (s *KBAPITestSuite) TestKibanaDashboard() {
 	if err = json.Unmarshal(b, &data); err != nil {
 		panic(err)
 	}
-	err = s.API.KibanaDashboard.Import(data, nil, true, "default")
+	importResult, err := s.API.KibanaDashboard.Import(data, nil, true, "default")
 	assert.NoError(s.T(), err)
+	assert.False(s.T(), importResult.HasErrors())
+	assert.Len(s.T(), importResult.Objects, 13)
 
 	// Export dashboard
 	data, err = s.API.KibanaDashboard.Export([]string{"edf84fe0-e1a0-11e7-b6d5-4dc382ef7f5b"}, "default")
@@ -35,7 +43,7 @@ func (s *KBAPITestSuite) TestKibanaDashboard() {
 	if err = json.Unmarshal(b, &data); err != nil {
 		panic(err)
 	}
-	err = s.API.KibanaDashboard.Import(data, nil, true, "testacc")
+	_, err = s.API.KibanaDashboard.Import(data, nil, true, "testacc")
 	assert.NoError(s.T(), err)
 
 	// Export dashboard from specific space
@@ -43,4 +51,71 @@ func (s *KBAPITestSuite) TestKibanaDashboard() {
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), data)
 
+	// Import dashboard with saved objects API
+	importResult, err = s.API.KibanaDashboard.ImportSavedObjects(data, []string{"index-pattern"}, true, "testacc")
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), importResult.Objects, 12)
+
+}
+
+func TestKibanaDashboardImport(t *testing.T) {
+
+	var query string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		switch r.URL.Path {
+		case "/s/testacc/api/kibana/dashboards/import":
+			_, _ = w.Write([]byte(`{"objects":[{"type":"dashboard","id":"d1","attributes":{"title":"Dashboard"}},{"type":"visualization","id":"v1","attributes":{"title":"Vis"},"error":{"statusCode":409,"message":"version conflict"}}]}`))
+		case "/api/kibana/dashboards/import":
+			_, _ = w.Write([]byte(`{"objects":[{"type":"dashboard","id":"d1","attributes":{"title":"Dashboard"}}]}`))
+		case "/api/saved_objects/_import":
+			file, _, _ := r.FormFile("file")
+			body, _ = io.ReadAll(file)
+			_, _ = w.Write([]byte(`{"success":false,"successCount":1,"successResults":[{"type":"dashboard","id":"d1","meta":{"title":"Dashboard"}}],"errors":[{"type":"visualization","id":"v1","title":"Vis","error":{"type":"conflict"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+
+	data := map[string]interface{}{
+		"version": "7.2.0",
+		"objects": []interface{}{
+			map[string]interface{}{"type": "dashboard", "id": "d1", "attributes": map[string]interface{}{"title": "Dashboard"}},
+			map[string]interface{}{"type": "visualization", "id": "v1", "attributes": map[string]interface{}{"title": "Vis"}},
+			map[string]interface{}{"type": "index-pattern", "id": "i1", "attributes": map[string]interface{}{"title": "logs-*"}},
+		},
+	}
+
+	// Force and exclude are both sent
+	result, err := api.KibanaDashboard.Import(data, []string{"index-pattern", "search"}, true, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "exclude=index-pattern%2Csearch&force=true", query)
+	assert.Equal(t, []KibanaDashboardImportObject{{Type: "dashboard", ID: "d1", Title: "Dashboard"}}, result.Objects)
+	assert.False(t, result.HasErrors())
+
+	// Errors by object
+	result, err = api.KibanaDashboard.Import(data, nil, false, "testacc")
+	apiErr := APIError{}
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, 600, apiErr.Code)
+	}
+	assert.Equal(t, "force=false", query)
+	assert.Len(t, result.Objects, 1)
+	assert.Equal(t, []KibanaDashboardImportError{{Type: "visualization", ID: "v1", Title: "Vis", StatusCode: 409, Message: "version conflict"}}, result.Errors)
+
+	// With saved objects import API
+	result, err = api.KibanaDashboard.ImportSavedObjects(data, []string{"index-pattern"}, true, "default")
+	assert.Error(t, err)
+	assert.Equal(t, "overwrite=true", query)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	assert.Len(t, lines, 2)
+	assert.JSONEq(t, `{"type":"dashboard","id":"d1","attributes":{"title":"Dashboard"}}`, lines[0])
+	assert.Equal(t, []KibanaDashboardImportObject{{Type: "dashboard", ID: "d1", Title: "Dashboard"}}, result.Objects)
+	assert.Equal(t, []KibanaDashboardImportError{{Type: "visualization", ID: "v1", Title: "Vis", StatusCode: 409, Message: "conflict"}}, result.Errors)
+
+	_, err = api.KibanaDashboard.ImportSavedObjects(map[string]interface{}{}, nil, true, "default")
+	assert.Error(t, err)
 }
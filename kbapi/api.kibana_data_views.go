@@ -0,0 +1,400 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	basePathKibanaDataViews = "/api/data_views" // Base URL to access on Kibana data views
+)
+
+// DataView is the data view object, formerly named index pattern
+type DataView struct {
+	ID            string                         `json:"id,omitempty"`
+	Version       string                         `json:"version,omitempty"`
+	Title         string                         `json:"title"`
+	Name          string                         `json:"name,omitempty"`
+	TimeFieldName string                         `json:"timeFieldName,omitempty"`
+	SourceFilters []DataViewSourceFilter         `json:"sourceFilters,omitempty"`
+	FieldFormats  map[string]DataViewFieldFormat `json:"fieldFormats,omitempty"`
+	FieldAttrs    map[string]DataViewFieldAttr   `json:"fieldAttrs,omitempty"`
+	AllowNoIndex  bool                           `json:"allowNoIndex,omitempty"`
+	Namespaces    []string                       `json:"namespaces,omitempty"`
+}
+
+// DataViewSourceFilter is the field pattern hidden on Discover document
+type DataViewSourceFilter struct {
+	Value string `json:"value"`
+}
+
+// DataViewFieldFormat is the format used to display the field value, like bytes or url
+type DataViewFieldFormat struct {
+	ID     string         `json:"id"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// DataViewFieldAttr is the custom label and popularity of field
+type DataViewFieldAttr struct {
+	CustomLabel string `json:"customLabel,omitempty"`
+	Count       int    `json:"count,omitempty"`
+}
+
+// DataViewSwapReferencesParameters is the references to move from one saved object to another
+// FromType default to index-pattern. ForID and ForType limit the objects to update
+type DataViewSwapReferencesParameters struct {
+	FromID   string   `json:"fromId"`
+	FromType string   `json:"fromType,omitempty"`
+	ToID     string   `json:"toId"`
+	ForID    []string `json:"forId,omitempty"`
+	ForType  string   `json:"forType,omitempty"`
+	Delete   bool     `json:"delete,omitempty"`
+}
+
+// DataViewSwapReferencesResult is the objects updated by swap references
+type DataViewSwapReferencesResult struct {
+	Result       []DataViewSwapReferencesObject      `json:"result"`
+	DeleteStatus *DataViewSwapReferencesDeleteStatus `json:"deleteStatus,omitempty"`
+}
+
+// DataViewSwapReferencesObject is the saved object updated by swap references
+type DataViewSwapReferencesObject struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// DataViewSwapReferencesDeleteStatus tell if the source saved object was deleted
+type DataViewSwapReferencesDeleteStatus struct {
+	RemainingRefs   int  `json:"remainingRefs"`
+	DeletePerformed bool `json:"deletePerformed"`
+}
+
+// dataViewUpdate is the data view fields Kibana accept on update
+// They are always sent, so empty values clear the field on Kibana
+type dataViewUpdate struct {
+	Title         string                         `json:"title,omitempty"`
+	Name          string                         `json:"name"`
+	TimeFieldName string                         `json:"timeFieldName"`
+	SourceFilters []DataViewSourceFilter         `json:"sourceFilters"`
+	FieldFormats  map[string]DataViewFieldFormat `json:"fieldFormats"`
+	AllowNoIndex  bool                           `json:"allowNoIndex"`
+}
+
+// KibanaDataViewGet permit to get data view by its ID
+type KibanaDataViewGet func(id string, kibanaSpace string) (*DataView, error)
+
+// KibanaDataViewList permit to get all data views
+type KibanaDataViewList func(kibanaSpace string) ([]DataView, error)
+
+// KibanaDataViewCreate permit to create data view. override replace the existing data view with same ID
+type KibanaDataViewCreate func(dataView *DataView, override bool, kibanaSpace string) (*DataView, error)
+
+// KibanaDataViewUpdate permit to update data view. refreshFields reload the field list from indices
+// It replace all updatable fields: the empty fields clear the value on Kibana, except title that is kept when empty.
+// Get the data view and modify it before update it to not lose the current values
+type KibanaDataViewUpdate func(dataView *DataView, refreshFields bool, kibanaSpace string) (*DataView, error)
+
+// KibanaDataViewDelete permit to delete data view
+type KibanaDataViewDelete func(id string, kibanaSpace string) error
+
+// KibanaDataViewSetDefault permit to set the default data view. force replace the existing default data view
+type KibanaDataViewSetDefault func(id string, force bool, kibanaSpace string) error
+
+// KibanaDataViewSwapReferences permit to move the references of saved objects from one data view to another.
+// preview return the objects that would be updated without update them
+type KibanaDataViewSwapReferences func(parameters *DataViewSwapReferencesParameters, preview bool, kibanaSpace string) (*DataViewSwapReferencesResult, error)
+
+// String permit to return DataView object as JSON string
+func (o *DataView) String() string {
+	json, _ := json.Marshal(o)
+	return string(json)
+}
+
+// String permit to return DataViewSwapReferencesResult object as JSON string
+func (o *DataViewSwapReferencesResult) String() string {
+	json, _ := json.Marshal(o)
+	return string(json)
+}
+
+// newKibanaDataViewGetFunc permit to get data view by its ID
+// It return nil if data view not exist
+func newKibanaDataViewGetFunc(c *resty.Client) KibanaDataViewGet {
+	return func(id string, kibanaSpace string) (*DataView, error) {
+
+		if id == "" {
+			return nil, NewAPIError(600, "You must provide data view ID")
+		}
+		log.Debug("ID: ", id)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		path := kibanaDataViewPath(kibanaSpace, fmt.Sprintf("/data_view/%s", url.PathEscape(id)))
+		resp, err := c.R().Get(path)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			if resp.StatusCode() == 404 {
+				return nil, nil
+			}
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		return unmarshalKibanaDataView(resp.Body())
+	}
+}
+
+// newKibanaDataViewListFunc permit to get all data views
+func newKibanaDataViewListFunc(c *resty.Client) KibanaDataViewList {
+	return func(kibanaSpace string) ([]DataView, error) {
+
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		resp, err := c.R().Get(kibanaDataViewPath(kibanaSpace, ""))
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		dataResponse := struct {
+			DataViews []DataView `json:"data_view"`
+		}{}
+		err = json.Unmarshal(resp.Body(), &dataResponse)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("DataViews: ", dataResponse.DataViews)
+
+		return dataResponse.DataViews, nil
+	}
+}
+
+// newKibanaDataViewCreateFunc permit to create data view
+func newKibanaDataViewCreateFunc(c *resty.Client) KibanaDataViewCreate {
+	return func(dataView *DataView, override bool, kibanaSpace string) (*DataView, error) {
+
+		if dataView == nil {
+			return nil, NewAPIError(600, "You must provide data view object")
+		}
+		if dataView.Title == "" {
+			return nil, NewAPIError(600, "You must provide data view title")
+		}
+		log.Debug("DataView: ", dataView)
+		log.Debug("Override: ", override)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		payload := map[string]any{
+			"data_view": dataView,
+			"override":  override,
+		}
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Payload: %s", jsonData)
+
+		resp, err := c.R().SetBody(jsonData).Post(kibanaDataViewPath(kibanaSpace, "/data_view"))
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		return unmarshalKibanaDataView(resp.Body())
+	}
+}
+
+// newKibanaDataViewUpdateFunc permit to update data view
+// The data view replace the current one, empty fields are cleared. Title is kept when empty.
+// ID, version, field attributes and namespaces can't be updated
+func newKibanaDataViewUpdateFunc(c *resty.Client) KibanaDataViewUpdate {
+	return func(dataView *DataView, refreshFields bool, kibanaSpace string) (*DataView, error) {
+
+		if dataView == nil {
+			return nil, NewAPIError(600, "You must provide data view object")
+		}
+		if dataView.ID == "" {
+			return nil, NewAPIError(600, "You must provide data view ID")
+		}
+		log.Debug("DataView: ", dataView)
+		log.Debug("RefreshFields: ", refreshFields)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		update := dataViewUpdate{
+			Title:         dataView.Title,
+			Name:          dataView.Name,
+			TimeFieldName: dataView.TimeFieldName,
+			SourceFilters: dataView.SourceFilters,
+			FieldFormats:  dataView.FieldFormats,
+			AllowNoIndex:  dataView.AllowNoIndex,
+		}
+		if update.SourceFilters == nil {
+			update.SourceFilters = make([]DataViewSourceFilter, 0)
+		}
+		if update.FieldFormats == nil {
+			update.FieldFormats = make(map[string]DataViewFieldFormat)
+		}
+		payload := map[string]any{
+			"data_view":      update,
+			"refresh_fields": refreshFields,
+		}
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Payload: %s", jsonData)
+
+		path := kibanaDataViewPath(kibanaSpace, fmt.Sprintf("/data_view/%s", url.PathEscape(dataView.ID)))
+		resp, err := c.R().SetBody(jsonData).Post(path)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			if resp.StatusCode() == 404 {
+				return nil, NewAPIError(404, "Data view %s not found", dataView.ID)
+			}
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		return unmarshalKibanaDataView(resp.Body())
+	}
+}
+
+// newKibanaDataViewDeleteFunc permit to delete data view
+func newKibanaDataViewDeleteFunc(c *resty.Client) KibanaDataViewDelete {
+	return func(id string, kibanaSpace string) error {
+
+		if id == "" {
+			return NewAPIError(600, "You must provide data view ID")
+		}
+		log.Debug("ID: ", id)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		path := kibanaDataViewPath(kibanaSpace, fmt.Sprintf("/data_view/%s", url.PathEscape(id)))
+		resp, err := c.R().Delete(path)
+		if err != nil {
+			return err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		return nil
+	}
+}
+
+// newKibanaDataViewSetDefaultFunc permit to set the default data view
+// Empty ID unset the default data view
+func newKibanaDataViewSetDefaultFunc(c *resty.Client) KibanaDataViewSetDefault {
+	return func(id string, force bool, kibanaSpace string) error {
+
+		log.Debug("ID: ", id)
+		log.Debug("Force: ", force)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		var dataViewID any
+		if id != "" {
+			dataViewID = id
+		}
+		payload := map[string]any{
+			"data_view_id": dataViewID,
+			"force":        force,
+		}
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Payload: %s", jsonData)
+
+		resp, err := c.R().SetBody(jsonData).Post(kibanaDataViewPath(kibanaSpace, "/default"))
+		if err != nil {
+			return err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		return nil
+	}
+}
+
+// newKibanaDataViewSwapReferencesFunc permit to move the references of saved objects from one data view to another
+func newKibanaDataViewSwapReferencesFunc(c *resty.Client) KibanaDataViewSwapReferences {
+	return func(parameters *DataViewSwapReferencesParameters, preview bool, kibanaSpace string) (*DataViewSwapReferencesResult, error) {
+
+		if parameters == nil {
+			return nil, NewAPIError(600, "You must provide swap references parameters")
+		}
+		if parameters.FromID == "" || parameters.ToID == "" {
+			return nil, NewAPIError(600, "You must provide from ID and to ID")
+		}
+		if preview && parameters.Delete {
+			return nil, NewAPIError(600, "Delete can't be used with preview")
+		}
+		log.Debug("Parameters: ", parameters)
+		log.Debug("Preview: ", preview)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		jsonData, err := json.Marshal(parameters)
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Payload: %s", jsonData)
+
+		path := kibanaDataViewPath(kibanaSpace, "/swap_references")
+		if preview {
+			path += "/_preview"
+		}
+		resp, err := c.R().SetBody(jsonData).Post(path)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		result := &DataViewSwapReferencesResult{}
+		err = json.Unmarshal(resp.Body(), result)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Result: ", result)
+
+		return result, nil
+	}
+}
+
+// kibanaDataViewPath return the data views API path on the user space
+func kibanaDataViewPath(kibanaSpace string, path string) string {
+	if kibanaSpace == "" || kibanaSpace == "default" {
+		return basePathKibanaDataViews + path
+	}
+	return fmt.Sprintf("/s/%s%s%s", kibanaSpace, basePathKibanaDataViews, path)
+}
+
+// unmarshalKibanaDataView read the data view wrapped on data_view key
+func unmarshalKibanaDataView(body []byte) (*DataView, error) {
+	dataResponse := struct {
+		DataView *DataView `json:"data_view"`
+	}{}
+	err := json.Unmarshal(body, &dataResponse)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("DataView: ", dataResponse.DataView)
+
+	return dataResponse.DataView, nil
+}
@@ -0,0 +1,196 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *KBAPITestSuite) TestKibanaDataViews() {
+
+	// Create new data view
+	dataView := &DataView{
+		ID:            "test-data-view",
+		Title:         "logstash-*",
+		Name:          "Logstash",
+		TimeFieldName: "@timestamp",
+		SourceFilters: []DataViewSourceFilter{{Value: "secret*"}},
+		FieldFormats: map[string]DataViewFieldFormat{
+			"bytes": {ID: "bytes"},
+		},
+		FieldAttrs: map[string]DataViewFieldAttr{
+			"host.name": {CustomLabel: "Host"},
+		},
+		AllowNoIndex: true,
+	}
+	dataView, err := s.API.KibanaDataViews.Create(dataView, true, "default")
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), dataView)
+	assert.Equal(s.T(), "test-data-view", dataView.ID)
+	assert.Equal(s.T(), "Host", dataView.FieldAttrs["host.name"].CustomLabel)
+
+	// Get data view
+	dataView, err = s.API.KibanaDataViews.Get("test-data-view", "default")
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), dataView)
+	assert.Equal(s.T(), "logstash-*", dataView.Title)
+	assert.Equal(s.T(), []string{"default"}, dataView.Namespaces)
+
+	// List data views
+	dataViews, err := s.API.KibanaDataViews.List("default")
+	assert.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), dataViews)
+
+	// Update data view
+	dataView.Name = "Logstash updated"
+	dataView, err = s.API.KibanaDataViews.Update(dataView, false, "default")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "Logstash updated", dataView.Name)
+
+	// Set default data view
+	err = s.API.KibanaDataViews.SetDefault("test-data-view", true, "default")
+	assert.NoError(s.T(), err)
+
+	// Preview swap references
+	result, err := s.API.KibanaDataViews.SwapReferences(&DataViewSwapReferencesParameters{FromID: "test-data-view", ToID: "other-data-view"}, true, "default")
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), result)
+
+	// Delete data view
+	err = s.API.KibanaDataViews.Delete("test-data-view", "default")
+	assert.NoError(s.T(), err)
+	dataView, err = s.API.KibanaDataViews.Get("test-data-view", "default")
+	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), dataView)
+}
+
+func TestKibanaDataViewsOffline(t *testing.T) {
+
+	var path string
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.Method + " " + r.URL.Path
+		payload = nil
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 0 {
+			_ = json.Unmarshal(body, &payload)
+		}
+		switch path {
+		case "GET /s/testacc/api/data_views/data_view/dv1", "POST /s/testacc/api/data_views/data_view", "POST /s/testacc/api/data_views/data_view/dv1":
+			_, _ = w.Write([]byte(`{"data_view":{"id":"dv1","version":"WzEsMV0=","title":"logs-*","name":"Logs","timeFieldName":"@timestamp","sourceFilters":[{"value":"secret*"}],"fieldFormats":{"bytes":{"id":"bytes","params":{"pattern":"0b"}}},"fieldAttrs":{"host.name":{"customLabel":"Host","count":2}},"allowNoIndex":true,"namespaces":["testacc"],"fields":{}}}`))
+		case "GET /api/data_views":
+			_, _ = w.Write([]byte(`{"data_view":[{"id":"dv1","title":"logs-*","name":"Logs","namespaces":["default"],"typeMeta":{}}]}`))
+		case "POST /api/data_views/swap_references/_preview":
+			_, _ = w.Write([]byte(`{"result":[{"id":"v1","type":"visualization"}]}`))
+		case "POST /api/data_views/default", "DELETE /api/data_views/data_view/dv1":
+			_, _ = w.Write([]byte(`{"acknowledged":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+
+	expected := &DataView{
+		ID:            "dv1",
+		Version:       "WzEsMV0=",
+		Title:         "logs-*",
+		Name:          "Logs",
+		TimeFieldName: "@timestamp",
+		SourceFilters: []DataViewSourceFilter{{Value: "secret*"}},
+		FieldFormats:  map[string]DataViewFieldFormat{"bytes": {ID: "bytes", Params: map[string]any{"pattern": "0b"}}},
+		FieldAttrs:    map[string]DataViewFieldAttr{"host.name": {CustomLabel: "Host", Count: 2}},
+		AllowNoIndex:  true,
+		Namespaces:    []string{"testacc"},
+	}
+
+	// Get
+	dataView, err := api.KibanaDataViews.Get("dv1", "testacc")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, dataView)
+	dataView, err = api.KibanaDataViews.Get("dv2", "default")
+	assert.NoError(t, err)
+	assert.Nil(t, dataView)
+
+	// List
+	dataViews, err := api.KibanaDataViews.List("")
+	assert.NoError(t, err)
+	assert.Equal(t, []DataView{{ID: "dv1", Title: "logs-*", Name: "Logs", Namespaces: []string{"default"}}}, dataViews)
+
+	// Create
+	_, err = api.KibanaDataViews.Create(&DataView{}, false, "testacc")
+	assert.Error(t, err)
+	dataView, err = api.KibanaDataViews.Create(&DataView{ID: "dv1", Title: "logs-*", TimeFieldName: "@timestamp"}, true, "testacc")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, dataView)
+	assert.Equal(t, map[string]any{
+		"data_view": map[string]any{"id": "dv1", "title": "logs-*", "timeFieldName": "@timestamp"},
+		"override":  true,
+	}, payload)
+
+	// Update only send updatable fields
+	dataView, err = api.KibanaDataViews.Update(expected, true, "testacc")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, dataView)
+	assert.Equal(t, map[string]any{
+		"data_view": map[string]any{
+			"title":         "logs-*",
+			"name":          "Logs",
+			"timeFieldName": "@timestamp",
+			"sourceFilters": []any{map[string]any{"value": "secret*"}},
+			"fieldFormats":  map[string]any{"bytes": map[string]any{"id": "bytes", "params": map[string]any{"pattern": "0b"}}},
+			"allowNoIndex":  true,
+		},
+		"refresh_fields": true,
+	}, payload)
+
+	// Update clear the empty fields
+	_, err = api.KibanaDataViews.Update(&DataView{ID: "dv1", Title: "logs-*", AllowNoIndex: false}, false, "testacc")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"data_view": map[string]any{
+			"title":         "logs-*",
+			"name":          "",
+			"timeFieldName": "",
+			"sourceFilters": []any{},
+			"fieldFormats":  map[string]any{},
+			"allowNoIndex":  false,
+		},
+		"refresh_fields": false,
+	}, payload)
+
+	_, err = api.KibanaDataViews.Update(&DataView{ID: "dv2", Title: "logs-*"}, false, "default")
+	apiErr := APIError{}
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, 404, apiErr.Code)
+	}
+
+	// Set default
+	err = api.KibanaDataViews.SetDefault("dv1", true, "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"data_view_id": "dv1", "force": true}, payload)
+	err = api.KibanaDataViews.SetDefault("", true, "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"data_view_id": nil, "force": true}, payload)
+
+	// Swap references
+	_, err = api.KibanaDataViews.SwapReferences(&DataViewSwapReferencesParameters{FromID: "dv1", ToID: "dv2", Delete: true}, true, "default")
+	assert.Error(t, err)
+	result, err := api.KibanaDataViews.SwapReferences(&DataViewSwapReferencesParameters{FromID: "dv1", ToID: "dv2", ForType: "visualization"}, true, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "POST /api/data_views/swap_references/_preview", path)
+	assert.Equal(t, map[string]any{"fromId": "dv1", "toId": "dv2", "forType": "visualization"}, payload)
+	assert.Equal(t, []DataViewSwapReferencesObject{{ID: "v1", Type: "visualization"}}, result.Result)
+
+	// Delete
+	err = api.KibanaDataViews.Delete("dv1", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE /api/data_views/data_view/dv1", path)
+	err = api.KibanaDataViews.Delete("dv2", "default")
+	assert.Error(t, err)
+}
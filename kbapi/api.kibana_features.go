@@ -0,0 +1,252 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	basePathKibanaFeatures = "/api/features" // Base URL to access on Kibana features
+)
+
+// KibanaFeature is the feature object
+type KibanaFeature struct {
+	ID                        string                   `json:"id"`
+	Name                      string                   `json:"name"`
+	Description               string                   `json:"description,omitempty"`
+	Order                     int                      `json:"order,omitempty"`
+	Category                  *KibanaFeatureCategory   `json:"category,omitempty"`
+	App                       []string                 `json:"app,omitempty"`
+	Catalogue                 []string                 `json:"catalogue,omitempty"`
+	Management                map[string][]string      `json:"management,omitempty"`
+	MinimumLicense            string                   `json:"minimumLicense,omitempty"`
+	Hidden                    bool                     `json:"hidden,omitempty"`
+	ExcludeFromBasePrivileges bool                     `json:"excludeFromBasePrivileges,omitempty"`
+	Privileges                *KibanaFeaturePrivileges `json:"privileges"`
+	SubFeatures               []KibanaSubFeature       `json:"subFeatures,omitempty"`
+	Reserved                  *KibanaFeatureReserved   `json:"reserved,omitempty"`
+}
+
+// KibanaFeatureCategory is the category where the feature is displayed
+type KibanaFeatureCategory struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Order       int    `json:"order,omitempty"`
+	EuiIconType string `json:"euiIconType,omitempty"`
+}
+
+// KibanaFeaturePrivileges is the all and read privileges of the feature
+type KibanaFeaturePrivileges struct {
+	All  *KibanaFeaturePrivilege `json:"all,omitempty"`
+	Read *KibanaFeaturePrivilege `json:"read,omitempty"`
+}
+
+// KibanaFeaturePrivilege is what a privilege grant on Kibana
+type KibanaFeaturePrivilege struct {
+	App              []string                           `json:"app,omitempty"`
+	Catalogue        []string                           `json:"catalogue,omitempty"`
+	API              []string                           `json:"api,omitempty"`
+	UI               []string                           `json:"ui,omitempty"`
+	SavedObject      *KibanaFeatureSavedObjectPrivilege `json:"savedObject,omitempty"`
+	Management       map[string][]string                `json:"management,omitempty"`
+	Disabled         bool                               `json:"disabled,omitempty"`
+	RequireAllSpaces bool                               `json:"requireAllSpaces,omitempty"`
+}
+
+// KibanaFeatureSavedObjectPrivilege is the saved object types granted by privilege
+type KibanaFeatureSavedObjectPrivilege struct {
+	All  []string `json:"all"`
+	Read []string `json:"read"`
+}
+
+// KibanaSubFeature is a sub feature that can be granted independently of the feature
+type KibanaSubFeature struct {
+	Name             string                           `json:"name"`
+	RequireAllSpaces bool                             `json:"requireAllSpaces,omitempty"`
+	PrivilegeGroups  []KibanaSubFeaturePrivilegeGroup `json:"privilegeGroups"`
+}
+
+// KibanaSubFeaturePrivilegeGroup is a group of sub feature privileges
+// GroupType is mutually_exclusive or independent
+type KibanaSubFeaturePrivilegeGroup struct {
+	GroupType  string                      `json:"groupType"`
+	Privileges []KibanaSubFeaturePrivilege `json:"privileges"`
+}
+
+// KibanaSubFeaturePrivilege is one sub feature privilege
+// IncludeIn is the feature privilege that already include it (all, read or none)
+type KibanaSubFeaturePrivilege struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IncludeIn string `json:"includeIn"`
+	KibanaFeaturePrivilege
+}
+
+// KibanaFeatureReserved is the privileges reserved to the system
+type KibanaFeatureReserved struct {
+	Description string                           `json:"description"`
+	Privileges  []KibanaFeatureReservedPrivilege `json:"privileges"`
+}
+
+// KibanaFeatureReservedPrivilege is one reserved privilege
+type KibanaFeatureReservedPrivilege struct {
+	ID        string                 `json:"id"`
+	Privilege KibanaFeaturePrivilege `json:"privilege"`
+}
+
+// KibanaFeatures is the list of KibanaFeature object
+type KibanaFeatures []KibanaFeature
+
+// KibanaFeatureList permit to get all features
+type KibanaFeatureList func() (KibanaFeatures, error)
+
+// String permit to return KibanaFeature object as JSON string
+func (f *KibanaFeature) String() string {
+	json, _ := json.Marshal(f)
+	return string(json)
+}
+
+// PrivilegeIDs return the privileges that can be granted on the feature by role
+// It's all and read, the minimal_all and minimal_read when feature has sub features, and sub features privileges
+func (f *KibanaFeature) PrivilegeIDs() []string {
+	privileges := make([]string, 0, 4)
+	if f.Privileges != nil {
+		if f.Privileges.All != nil {
+			privileges = append(privileges, "all")
+		}
+		if f.Privileges.Read != nil {
+			privileges = append(privileges, "read")
+		}
+	}
+	if len(f.SubFeatures) > 0 {
+		if f.Privileges != nil && f.Privileges.All != nil {
+			privileges = append(privileges, "minimal_all")
+		}
+		if f.Privileges != nil && f.Privileges.Read != nil {
+			privileges = append(privileges, "minimal_read")
+		}
+	}
+	for _, subFeature := range f.SubFeatures {
+		for _, group := range subFeature.PrivilegeGroups {
+			for _, privilege := range group.Privileges {
+				privileges = append(privileges, privilege.ID)
+			}
+		}
+	}
+
+	return privileges
+}
+
+// Get return the feature with it ID, or nil if not exist
+func (f KibanaFeatures) Get(id string) *KibanaFeature {
+	for i := range f {
+		if f[i].ID == id {
+			return &f[i]
+		}
+	}
+	return nil
+}
+
+// ValidateDisabledFeatures check that all disabled features exist on Kibana
+func (f KibanaFeatures) ValidateDisabledFeatures(disabledFeatures []string) error {
+	var errors []string
+	for _, id := range disabledFeatures {
+		if f.Get(id) == nil {
+			errors = append(errors, fmt.Sprintf("Feature %s not exist", id))
+		}
+	}
+	if len(errors) > 0 {
+		return NewAPIError(600, strings.Join(errors, "\n"))
+	}
+
+	return nil
+}
+
+// ValidateSpace check that disabled features of space exist on Kibana
+func (f KibanaFeatures) ValidateSpace(kibanaSpace *KibanaSpace) error {
+	if kibanaSpace == nil {
+		return NewAPIError(600, "You must provide kibana space object")
+	}
+	return f.ValidateDisabledFeatures(kibanaSpace.DisabledFeatures)
+}
+
+// ValidateRole check that features and privileges granted by role exist on Kibana
+func (f KibanaFeatures) ValidateRole(kibanaRole *KibanaRole) error {
+	if kibanaRole == nil {
+		return NewAPIError(600, "You must provide kibana role object")
+	}
+
+	var errors []string
+	for _, kibana := range kibanaRole.Kibana {
+		for _, base := range kibana.Base {
+			if base != "all" && base != "read" {
+				errors = append(errors, fmt.Sprintf("Base privilege %s not exist", base))
+			}
+		}
+
+		// Sort features to have stable error message
+		ids := make([]string, 0, len(kibana.Feature))
+		for id := range kibana.Feature {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			feature := f.Get(id)
+			if feature == nil {
+				errors = append(errors, fmt.Sprintf("Feature %s not exist", id))
+				continue
+			}
+			privilegeIDs := feature.PrivilegeIDs()
+			for _, privilege := range kibana.Feature[id] {
+				if !stringInSlice(privilege, privilegeIDs) {
+					errors = append(errors, fmt.Sprintf("Privilege %s not exist on feature %s, expected one of %s", privilege, id, strings.Join(privilegeIDs, ", ")))
+				}
+			}
+		}
+	}
+	if len(errors) > 0 {
+		return NewAPIError(600, strings.Join(errors, "\n"))
+	}
+
+	return nil
+}
+
+// newKibanaFeatureListFunc permit to get all Kibana features
+func newKibanaFeatureListFunc(c *resty.Client) KibanaFeatureList {
+	return func() (KibanaFeatures, error) {
+
+		resp, err := c.R().Get(basePathKibanaFeatures)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+		kibanaFeatures := make(KibanaFeatures, 0, 1)
+		err = json.Unmarshal(resp.Body(), &kibanaFeatures)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("KibanaFeatures: ", kibanaFeatures)
+
+		return kibanaFeatures, nil
+	}
+
+}
+
+// stringInSlice return true if value is in list
+func stringInSlice(value string, list []string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package kbapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *KBAPITestSuite) TestKibanaFeatures() {
+
+	// List features
+	kibanaFeatures, err := s.API.KibanaFeatures.List()
+	assert.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), kibanaFeatures)
+	discover := kibanaFeatures.Get("discover")
+	assert.NotNil(s.T(), discover)
+	assert.Contains(s.T(), discover.PrivilegeIDs(), "all")
+
+	// Validate space
+	err = kibanaFeatures.ValidateSpace(&KibanaSpace{ID: "test", Name: "test", DisabledFeatures: []string{"discover", "dashboard"}})
+	assert.NoError(s.T(), err)
+	err = kibanaFeatures.ValidateSpace(&KibanaSpace{ID: "test", Name: "test", DisabledFeatures: []string{"dashbaord"}})
+	assert.Error(s.T(), err)
+
+	// Validate role
+	err = kibanaFeatures.ValidateRole(&KibanaRole{Kibana: []KibanaRoleKibana{{Feature: map[string][]string{"discover": {"read"}}}}})
+	assert.NoError(s.T(), err)
+	err = kibanaFeatures.ValidateRole(&KibanaRole{Kibana: []KibanaRoleKibana{{Feature: map[string][]string{"discover": {"write"}}}}})
+	assert.Error(s.T(), err)
+}
+
+func TestKibanaFeaturesValidate(t *testing.T) {
+
+	kibanaFeatures := KibanaFeatures{
+		{
+			ID:   "discover",
+			Name: "Discover",
+			Privileges: &KibanaFeaturePrivileges{
+				All:  &KibanaFeaturePrivilege{},
+				Read: &KibanaFeaturePrivilege{},
+			},
+			SubFeatures: []KibanaSubFeature{
+				{
+					Name: "Short URLs",
+					PrivilegeGroups: []KibanaSubFeaturePrivilegeGroup{
+						{
+							GroupType: "independent",
+							Privileges: []KibanaSubFeaturePrivilege{
+								{
+									ID:        "url_create",
+									Name:      "Create Short URLs",
+									IncludeIn: "all",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			ID:   "dev_tools",
+			Name: "Dev Tools",
+			Privileges: &KibanaFeaturePrivileges{
+				All: &KibanaFeaturePrivilege{},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"all", "read", "minimal_all", "minimal_read", "url_create"}, kibanaFeatures.Get("discover").PrivilegeIDs())
+	assert.Equal(t, []string{"all"}, kibanaFeatures.Get("dev_tools").PrivilegeIDs())
+	assert.Nil(t, kibanaFeatures.Get("dashboard"))
+
+	// Disabled features
+	assert.NoError(t, kibanaFeatures.ValidateDisabledFeatures([]string{"discover", "dev_tools"}))
+	assert.Error(t, kibanaFeatures.ValidateDisabledFeatures([]string{"discover", "dashboard"}))
+	assert.Error(t, kibanaFeatures.ValidateSpace(nil))
+
+	// Role features
+	kibanaRole := &KibanaRole{
+		Kibana: []KibanaRoleKibana{
+			{
+				Feature: map[string][]string{
+					"discover":  {"minimal_read", "url_create"},
+					"dev_tools": {"all"},
+				},
+				Spaces: []string{"default"},
+			},
+			{
+				Base:   []string{"read"},
+				Spaces: []string{"test"},
+			},
+		},
+	}
+	assert.NoError(t, kibanaFeatures.ValidateRole(kibanaRole))
+
+	kibanaRole.Kibana[0].Feature["dev_tools"] = []string{"read"}
+	kibanaRole.Kibana[0].Feature["dashbaord"] = []string{"all"}
+	kibanaRole.Kibana[1].Base = []string{"write"}
+	err := kibanaFeatures.ValidateRole(kibanaRole)
+	assert.Error(t, err)
+	assert.Equal(t, "Feature dashbaord not exist\nPrivilege read not exist on feature dev_tools, expected one of all\nBase privilege write not exist", err.Error())
+}
@@ -3,6 +3,7 @@ package kbapi
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 
 	"github.com/go-resty/resty/v2"
 	log "github.com/sirupsen/logrus"
@@ -12,20 +13,61 @@ const (
 	basePathKibanaLogstashPipeline = "/api/logstash/pipeline" // Base URL to access on Kibana Logstash pipeline
 )
 
+// Queue type of Logstash pipeline
+const (
+	LogstashPipelineQueueMemory    = "memory"
+	LogstashPipelineQueuePersisted = "persisted"
+)
+
+var (
+	logstashPipelineQueueMaxBytesRegexp = regexp.MustCompile(`^[0-9]+(b|kb|mb|gb|tb|pb)$`)
+	logstashPipelineSettingsFields      = []string{"pipeline.workers", "pipeline.batch.size", "pipeline.batch.delay", "queue.type", "queue.max_bytes", "queue.checkpoint.writes"}
+)
+
 // LogstashPipeline is the Logstash pipeline object
 type LogstashPipeline struct {
-	ID          string                 `json:"id"`
-	Description string                 `json:"description,omitempty"`
-	Pipeline    string                 `json:"pipeline,omitempty"`
-	Settings    map[string]interface{} `json:"settings,omitempty"`
-	Username    string                 `json:"username,omitempty"`
+	ID          string                    `json:"id"`
+	Description string                    `json:"description,omitempty"`
+	Pipeline    string                    `json:"pipeline,omitempty"`
+	Settings    *LogstashPipelineSettings `json:"settings,omitempty"`
+	Username    string                    `json:"username,omitempty"`
 }
 
+// LogstashPipelineSettings is the Logstash pipeline settings object
+// Settings not set use the Logstash default value.
+// Extra contain the settings not handled by this library, like queue.page_capacity. They are sent back as is.
+type LogstashPipelineSettings struct {
+	// PipelineWorkers is the number of workers that execute filters and outputs
+	PipelineWorkers *int `json:"pipeline.workers,omitempty"`
+	// PipelineBatchSize is the maximum number of events collected by worker before execute filters and outputs
+	PipelineBatchSize *int `json:"pipeline.batch.size,omitempty"`
+	// PipelineBatchDelay is the time in milliseconds to wait for new event before dispatch undersized batch
+	PipelineBatchDelay *int `json:"pipeline.batch.delay,omitempty"`
+	// QueueType is memory or persisted
+	QueueType string `json:"queue.type,omitempty"`
+	// QueueMaxBytes is the capacity of persisted queue with unit, like 1gb
+	QueueMaxBytes string `json:"queue.max_bytes,omitempty"`
+	// QueueCheckpointWrites is the maximum number of events written before forcing checkpoint. 0 is unlimited
+	QueueCheckpointWrites *int `json:"queue.checkpoint.writes,omitempty"`
+	// Extra is the other settings
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type logstashPipelineSettingsJSON LogstashPipelineSettings
+
 type LogstashPipelineRequest struct {
-	Description string                 `json:"description,omitempty"`
-	Pipeline    string                 `json:"pipeline,omitempty"`
-	Settings    map[string]interface{} `json:"settings,omitempty"`
-	Username    string                 `json:"username,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Pipeline    string                    `json:"pipeline,omitempty"`
+	Settings    *LogstashPipelineSettings `json:"settings,omitempty"`
+	Username    string                    `json:"username,omitempty"`
+}
+
+// LogstashPipelinesBulkDeleteResult is the result of bulk delete
+type LogstashPipelinesBulkDeleteResult struct {
+	NumSuccesses int      `json:"numSuccesses"`
+	NumErrors    int      `json:"numErrors"`
+	Successes    []string `json:"-"`
+	Errors       []string `json:"-"`
 }
 
 // LogstashPipelinesList is the logstash pipeline list result when get the list
@@ -36,8 +78,18 @@ type LogstashPipelinesList struct {
 // LogstashPipelines is list of Logstash pipeline object
 type LogstashPipelines []LogstashPipeline
 
+// OptionalCreateOrUpdatePipelineParameters is the optional parameters when create or update logstash pipeline
+type OptionalCreateOrUpdatePipelineParameters struct {
+	// SkipFetch only send the write request, without get the pipeline before and after it.
+	// The pipeline sent is returned, and created is always false because it can't be known
+	SkipFetch bool
+}
+
 // KibanaLogstashPipelineCreateOrUpdate permit to create or update logstash pipeline
-type KibanaLogstashPipelineCreateOrUpdate func(logstashPipeline *LogstashPipeline) (*LogstashPipeline, error)
+// created is true when the pipeline not exist before. Kibana not tell it on write, so the pipeline is get
+// before and after the write: use SkipFetch to do only one request, like when provision many pipelines.
+// With SkipFetch, created has no meaning and is always false: it not tell if the pipeline is created or updated
+type KibanaLogstashPipelineCreateOrUpdate func(logstashPipeline *LogstashPipeline, params *OptionalCreateOrUpdatePipelineParameters) (result *LogstashPipeline, created bool, err error)
 
 // KibanaLogstashPipelineGet permit to get the logstash pipeline
 type KibanaLogstashPipelineGet func(id string) (*LogstashPipeline, error)
@@ -48,12 +100,66 @@ type KibanaLogstashPipelineList func() (LogstashPipelines, error)
 // KibanaLogstashPipelineDelete permit to delete the logstash pipeline
 type KibanaLogstashPipelineDelete func(id string) error
 
+// KibanaLogstashPipelineBulkDelete permit to delete many logstash pipelines
+type KibanaLogstashPipelineBulkDelete func(ids []string) (*LogstashPipelinesBulkDeleteResult, error)
+
 // String permit to return LogstashPipeline object as JSON string
 func (o *LogstashPipeline) String() string {
 	json, _ := json.Marshal(o)
 	return string(json)
 }
 
+// MarshalJSON permit to add the extra settings
+func (o LogstashPipelineSettings) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(logstashPipelineSettingsJSON(o))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtraFields(data, o.Extra)
+}
+
+// UnmarshalJSON permit to keep unknown settings on Extra
+func (o *LogstashPipelineSettings) UnmarshalJSON(data []byte) error {
+	settings := logstashPipelineSettingsJSON{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, logstashPipelineSettingsFields)
+	if err != nil {
+		return err
+	}
+	settings.Extra = extra
+
+	*o = LogstashPipelineSettings(settings)
+	return nil
+}
+
+// Validate permit to check the settings values before send them to Kibana
+func (o *LogstashPipelineSettings) Validate() error {
+	if o.PipelineWorkers != nil && *o.PipelineWorkers < 1 {
+		return NewAPIError(600, "Setting pipeline.workers must be greater than 0")
+	}
+	if o.PipelineBatchSize != nil && *o.PipelineBatchSize < 1 {
+		return NewAPIError(600, "Setting pipeline.batch.size must be greater than 0")
+	}
+	if o.PipelineBatchDelay != nil && *o.PipelineBatchDelay < 0 {
+		return NewAPIError(600, "Setting pipeline.batch.delay must be positive")
+	}
+	switch o.QueueType {
+	case "", LogstashPipelineQueueMemory, LogstashPipelineQueuePersisted:
+	default:
+		return NewAPIError(600, "Setting queue.type must be %s or %s", LogstashPipelineQueueMemory, LogstashPipelineQueuePersisted)
+	}
+	if o.QueueMaxBytes != "" && !logstashPipelineQueueMaxBytesRegexp.MatchString(o.QueueMaxBytes) {
+		return NewAPIError(600, "Setting queue.max_bytes %s must be a number followed by unit b, kb, mb, gb, tb or pb", o.QueueMaxBytes)
+	}
+	if o.QueueCheckpointWrites != nil && *o.QueueCheckpointWrites < 0 {
+		return NewAPIError(600, "Setting queue.checkpoint.writes must be positive")
+	}
+
+	return nil
+}
+
 // newKibanaLogstashPipelineGetFunc permit to get the kibana role with it name
 func newKibanaLogstashPipelineGetFunc(c *resty.Client) KibanaLogstashPipelineGet {
 	return func(id string) (*LogstashPipeline, error) {
@@ -111,50 +217,83 @@ func newKibanaLogstashPipelineListFunc(c *resty.Client) KibanaLogstashPipelineLi
 
 }
 
-// newKibanaPipelineCreateOrUpdateFunc permit to create or update logstash pipeline
+// newKibanaLogstashPipelineCreateOrUpdateFunc permit to create or update logstash pipeline
 func newKibanaLogstashPipelineCreateOrUpdateFunc(c *resty.Client) KibanaLogstashPipelineCreateOrUpdate {
-	return func(logstashPipeline *LogstashPipeline) (*LogstashPipeline, error) {
+	return func(logstashPipeline *LogstashPipeline, params *OptionalCreateOrUpdatePipelineParameters) (*LogstashPipeline, bool, error) {
 
 		if logstashPipeline == nil {
-			return nil, NewAPIError(600, "You must provide the logstash pipeline object")
+			return nil, false, NewAPIError(600, "You must provide the logstash pipeline object")
+		}
+		if logstashPipeline.ID == "" {
+			return nil, false, NewAPIError(600, "You must provide the logstash pipeline ID")
+		}
+		if params == nil {
+			params = &OptionalCreateOrUpdatePipelineParameters{}
 		}
 
 		log.Debug("LogstashPipeline: ", logstashPipeline)
+		log.Debug("Params: ", params)
+		if logstashPipeline.Pipeline != "" {
+			if _, err := ParseLogstashPipelineConfig(logstashPipeline.Pipeline); err != nil {
+				return nil, false, err
+			}
+		}
+		if logstashPipeline.Settings != nil {
+			if err := logstashPipeline.Settings.Validate(); err != nil {
+				return nil, false, err
+			}
+		}
+
+		// Check if the pipeline already exist to know if it's created or updated
+		created := false
+		if !params.SkipFetch {
+			currentLogstashPipeline, err := newKibanaLogstashPipelineGetFunc(c)(logstashPipeline.ID)
+			if err != nil {
+				return nil, false, err
+			}
+			created = currentLogstashPipeline == nil
+		}
 
 		logstashPipelineRequest := &LogstashPipelineRequest{
 			Description: logstashPipeline.Description,
 			Pipeline:    logstashPipeline.Pipeline,
 			Settings:    logstashPipeline.Settings,
+			Username:    logstashPipeline.Username,
 		}
 
 		jsonData, err := json.Marshal(logstashPipelineRequest)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 
 		path := fmt.Sprintf("%s/%s", basePathKibanaLogstashPipeline, logstashPipeline.ID)
 		resp, err := c.R().SetBody(jsonData).Put(path)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 
 		log.Debug("Response: ", resp)
 		if resp.StatusCode() >= 300 {
-			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+			return nil, false, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		if params.SkipFetch {
+			result := *logstashPipeline
+			return &result, false, nil
 		}
 
 		// Retrive the object to return it
-		logstashPipeline, err = newKibanaLogstashPipelineGetFunc(c)(logstashPipeline.ID)
+		result, err := newKibanaLogstashPipelineGetFunc(c)(logstashPipeline.ID)
 		if err != nil {
-			return nil, err
+			return nil, created, err
 		}
-		if logstashPipeline == nil {
-			return nil, NewAPIError(404, "Logstash pipeline %s not found", logstashPipeline.ID)
+		if result == nil {
+			return nil, created, NewAPIError(404, "Logstash pipeline %s not found", logstashPipeline.ID)
 		}
 
-		log.Debug("logstashPipeline: ", logstashPipeline)
+		log.Debug("logstashPipeline: ", result)
 
-		return logstashPipeline, nil
+		return result, created, nil
 	}
 }
 
@@ -180,3 +319,57 @@ func newKibanaLogstashPipelineDeleteFunc(c *resty.Client) KibanaLogstashPipeline
 		return nil
 	}
 }
+
+// newKibanaLogstashPipelineBulkDeleteFunc permit to delete many logstash pipelines with their IDs.
+// Kibana only return the number of errors, so the pipelines still existing are reported on Errors
+func newKibanaLogstashPipelineBulkDeleteFunc(c *resty.Client) KibanaLogstashPipelineBulkDelete {
+	return func(ids []string) (*LogstashPipelinesBulkDeleteResult, error) {
+
+		if len(ids) == 0 {
+			return nil, NewAPIError(600, "You must provide one or more logstash pipeline IDs")
+		}
+		log.Debug("IDs: ", ids)
+
+		jsonData, err := json.Marshal(map[string]interface{}{
+			"pipelineIds": ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		path := fmt.Sprintf("%ss/delete", basePathKibanaLogstashPipeline)
+		resp, err := c.R().SetBody(jsonData).Post(path)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+		result := &LogstashPipelinesBulkDeleteResult{}
+		err = json.Unmarshal(resp.Body(), result)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.NumErrors == 0 {
+			result.Successes = ids
+		} else {
+			// Search the pipelines not deleted
+			get := newKibanaLogstashPipelineGetFunc(c)
+			for _, id := range ids {
+				logstashPipeline, err := get(id)
+				if err != nil {
+					return nil, err
+				}
+				if logstashPipeline == nil {
+					result.Successes = append(result.Successes, id)
+				} else {
+					result.Errors = append(result.Errors, id)
+				}
+			}
+		}
+		log.Debug("Result: ", result)
+
+		return result, nil
+	}
+}
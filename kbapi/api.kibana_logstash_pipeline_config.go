@@ -0,0 +1,669 @@
+package kbapi
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Section types of Logstash pipeline
+const (
+	LogstashPipelineSectionInput  = "input"
+	LogstashPipelineSectionFilter = "filter"
+	LogstashPipelineSectionOutput = "output"
+)
+
+// LogstashPipelineConfig is the parsed Logstash pipeline
+type LogstashPipelineConfig struct {
+	Sections []LogstashPipelineSection
+}
+
+// LogstashPipelineSection is input, filter or output section
+// Plugins contain the plugins of the section, including those inside conditionals
+type LogstashPipelineSection struct {
+	Type    string
+	Line    int
+	Column  int
+	Plugins []LogstashPipelinePlugin
+}
+
+// LogstashPipelinePlugin is a plugin block, like stdin {}
+type LogstashPipelinePlugin struct {
+	Name       string
+	Line       int
+	Column     int
+	Attributes []LogstashPipelineAttribute
+}
+
+// LogstashPipelineAttribute is plugin setting.
+// Value is string (string or bareword), float64, []interface{}, map[string]interface{} or *LogstashPipelinePlugin (codec)
+type LogstashPipelineAttribute struct {
+	Name  string
+	Value interface{}
+}
+
+// LogstashPipelineConfigError is the syntax error found on Logstash pipeline
+type LogstashPipelineConfigError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// Error return the error message with position
+func (e *LogstashPipelineConfigError) Error() string {
+	return fmt.Sprintf("Logstash pipeline syntax error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ParseLogstashPipelineConfig parse the Logstash config language.
+// It return *LogstashPipelineConfigError when the pipeline is invalid
+func ParseLogstashPipelineConfig(config string) (*LogstashPipelineConfig, error) {
+	p := &logstashConfigParser{
+		input: []rune(config),
+		cursor: logstashConfigCursor{
+			line:   1,
+			column: 1,
+		},
+	}
+	return p.parse()
+}
+
+// logstashConfigCursor is the position on pipeline
+type logstashConfigCursor struct {
+	offset int
+	line   int
+	column int
+}
+
+// logstashConfigParser is recursive descent parser that follow the Logstash treetop grammar
+type logstashConfigParser struct {
+	input  []rune
+	cursor logstashConfigCursor
+}
+
+func (p *logstashConfigParser) parse() (*LogstashPipelineConfig, error) {
+	config := &LogstashPipelineConfig{}
+
+	p.skip()
+	if p.eof() {
+		return nil, p.errorf(p.cursor, "pipeline must contain input, filter or output section")
+	}
+	for !p.eof() {
+		section, err := p.parseSection()
+		if err != nil {
+			return nil, err
+		}
+		config.Sections = append(config.Sections, *section)
+		p.skip()
+	}
+
+	return config, nil
+}
+
+// parseSection parse input, filter or output section
+func (p *logstashConfigParser) parseSection() (*LogstashPipelineSection, error) {
+	start := p.cursor
+	name := p.readName()
+	switch name {
+	case LogstashPipelineSectionInput, LogstashPipelineSectionFilter, LogstashPipelineSectionOutput:
+	default:
+		return nil, p.errorf(start, "expected input, filter or output section, found %s", p.describe(start, name))
+	}
+
+	section := &LogstashPipelineSection{
+		Type:   name,
+		Line:   start.line,
+		Column: start.column,
+	}
+	p.skip()
+	if err := p.expect("{", "after "+name); err != nil {
+		return nil, err
+	}
+	if err := p.parseBlock(section); err != nil {
+		return nil, err
+	}
+
+	return section, nil
+}
+
+// parseBlock parse plugins and conditionals until the closing brace
+func (p *logstashConfigParser) parseBlock(section *LogstashPipelineSection) error {
+	for {
+		p.skip()
+		if p.consume("}") {
+			return nil
+		}
+		if p.eof() {
+			return p.errorf(p.cursor, "expected } to close %s section", section.Type)
+		}
+
+		if p.hasKeyword("if") {
+			if err := p.parseBranch(section); err != nil {
+				return err
+			}
+			continue
+		}
+		plugin, err := p.parsePlugin("")
+		if err != nil {
+			return err
+		}
+		section.Plugins = append(section.Plugins, *plugin)
+	}
+}
+
+// parseBranch parse if, else if and else blocks
+func (p *logstashConfigParser) parseBranch(section *LogstashPipelineSection) error {
+	p.consume("if")
+	for {
+		p.skip()
+		if err := p.parseCondition(); err != nil {
+			return err
+		}
+		p.skip()
+		if err := p.expect("{", "after condition"); err != nil {
+			return err
+		}
+		if err := p.parseBlock(section); err != nil {
+			return err
+		}
+
+		saved := p.cursor
+		p.skip()
+		if !p.hasKeyword("else") {
+			p.cursor = saved
+			return nil
+		}
+		p.consume("else")
+		p.skip()
+		if p.hasKeyword("if") {
+			p.consume("if")
+			continue
+		}
+		if err := p.expect("{", "after else"); err != nil {
+			return err
+		}
+		return p.parseBlock(section)
+	}
+}
+
+// parsePlugin parse the plugin block. The name is already read when plugin is used as value
+func (p *logstashConfigParser) parsePlugin(name string) (*LogstashPipelinePlugin, error) {
+	start := p.cursor
+	if name == "" {
+		var err error
+		if name, err = p.parseName("plugin name or if"); err != nil {
+			return nil, err
+		}
+		p.skip()
+	}
+
+	plugin := &LogstashPipelinePlugin{
+		Name:   name,
+		Line:   start.line,
+		Column: start.column,
+	}
+	if err := p.expect("{", "after plugin "+name); err != nil {
+		return nil, err
+	}
+	for {
+		p.skip()
+		if p.consume("}") {
+			return plugin, nil
+		}
+		if p.eof() {
+			return nil, p.errorf(p.cursor, "expected } to close plugin %s", name)
+		}
+
+		attributeName, err := p.parseName(fmt.Sprintf("setting name or } in plugin %s", name))
+		if err != nil {
+			return nil, err
+		}
+		p.skip()
+		if err = p.expect("=>", "after setting "+attributeName); err != nil {
+			return nil, err
+		}
+		p.skip()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		plugin.Attributes = append(plugin.Attributes, LogstashPipelineAttribute{
+			Name:  attributeName,
+			Value: value,
+		})
+	}
+}
+
+// parseValue parse the setting value
+func (p *logstashConfigParser) parseValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.parseString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseHash()
+	case c == '-' || isLogstashConfigDigit(c):
+		return p.parseNumber()
+	case isLogstashConfigNameChar(c):
+		name := p.readName()
+		saved := p.cursor
+		p.skip()
+		if p.peek() == '{' {
+			return p.parsePlugin(name)
+		}
+		p.cursor = saved
+		return name, nil
+	default:
+		return nil, p.errorf(p.cursor, "expected value, found %s", p.describe(p.cursor, ""))
+	}
+}
+
+// parseString parse single or double quoted string and return its raw content
+func (p *logstashConfigParser) parseString() (string, error) {
+	start := p.cursor
+	quote := p.next()
+	begin := p.cursor.offset
+	for {
+		if p.eof() {
+			return "", p.errorf(start, "unterminated string")
+		}
+		c := p.next()
+		if c == '\\' && !p.eof() {
+			p.next()
+			continue
+		}
+		if c == quote {
+			return string(p.input[begin : p.cursor.offset-1]), nil
+		}
+	}
+}
+
+// parseNumber parse integer or decimal number
+func (p *logstashConfigParser) parseNumber() (float64, error) {
+	start := p.cursor
+	p.consume("-")
+	if !isLogstashConfigDigit(p.peek()) {
+		return 0, p.errorf(start, "invalid number")
+	}
+	for isLogstashConfigDigit(p.peek()) {
+		p.next()
+	}
+	if p.consume(".") {
+		for isLogstashConfigDigit(p.peek()) {
+			p.next()
+		}
+	}
+	number, err := strconv.ParseFloat(string(p.input[start.offset:p.cursor.offset]), 64)
+	if err != nil {
+		return 0, p.errorf(start, "invalid number")
+	}
+	return number, nil
+}
+
+// parseArray parse array of values separated by comma
+func (p *logstashConfigParser) parseArray() ([]interface{}, error) {
+	p.consume("[")
+	values := make([]interface{}, 0)
+	p.skip()
+	if p.consume("]") {
+		return values, nil
+	}
+	for {
+		p.skip()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.skip()
+		if p.consume(",") {
+			continue
+		}
+		if p.consume("]") {
+			return values, nil
+		}
+		return nil, p.errorf(p.cursor, "expected , or ] in array, found %s", p.describe(p.cursor, ""))
+	}
+}
+
+// parseHash parse hash entries separated by spaces
+func (p *logstashConfigParser) parseHash() (map[string]interface{}, error) {
+	p.consume("{")
+	hash := make(map[string]interface{})
+	for {
+		p.skip()
+		if p.consume("}") {
+			return hash, nil
+		}
+		if p.eof() {
+			return nil, p.errorf(p.cursor, "expected } to close hash")
+		}
+
+		var key string
+		var err error
+		if c := p.peek(); c == '-' || isLogstashConfigDigit(c) {
+			start := p.cursor
+			if _, err = p.parseNumber(); err != nil {
+				return nil, err
+			}
+			key = string(p.input[start.offset:p.cursor.offset])
+		} else if key, err = p.parseName("hash key or }"); err != nil {
+			return nil, err
+		}
+		p.skip()
+		if err = p.expect("=>", "after hash key "+key); err != nil {
+			return nil, err
+		}
+		p.skip()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		hash[key] = value
+	}
+}
+
+// parseCondition parse expressions joined by boolean operators
+func (p *logstashConfigParser) parseCondition() error {
+	for {
+		if err := p.parseExpression(); err != nil {
+			return err
+		}
+		saved := p.cursor
+		p.skip()
+		operator := false
+		for _, keyword := range []string{"and", "or", "xor", "nand"} {
+			if p.hasKeyword(keyword) {
+				p.consume(keyword)
+				operator = true
+				break
+			}
+		}
+		if !operator {
+			p.cursor = saved
+			return nil
+		}
+		p.skip()
+	}
+}
+
+// parseExpression parse one expression of condition
+func (p *logstashConfigParser) parseExpression() error {
+	if p.consume("(") {
+		return p.parseParenthesisCondition()
+	}
+	if p.peek() == '!' && !p.hasPrefix("!=") && !p.hasPrefix("!~") {
+		p.next()
+		p.skip()
+		if p.consume("(") {
+			return p.parseParenthesisCondition()
+		}
+		if p.peek() != '[' || !p.parseSelector() {
+			return p.errorf(p.cursor, "expected ( or field reference after !, found %s", p.describe(p.cursor, ""))
+		}
+		return nil
+	}
+
+	if err := p.parseRValue(); err != nil {
+		return err
+	}
+	saved := p.cursor
+	p.skip()
+	switch {
+	case p.consume("=~") || p.consume("!~"):
+		p.skip()
+		switch p.peek() {
+		case '"', '\'':
+			_, err := p.parseString()
+			return err
+		case '/':
+			return p.parseRegexp()
+		default:
+			return p.errorf(p.cursor, "expected regexp or string, found %s", p.describe(p.cursor, ""))
+		}
+	case p.consume("=="), p.consume("!="), p.consume("<="), p.consume(">="), p.consume("<"), p.consume(">"):
+		p.skip()
+		return p.parseRValue()
+	case p.hasKeyword("in"):
+		p.consume("in")
+		p.skip()
+		return p.parseRValue()
+	case p.hasKeyword("not"):
+		p.consume("not")
+		p.skip()
+		if !p.hasKeyword("in") {
+			return p.errorf(p.cursor, "expected in after not, found %s", p.describe(p.cursor, ""))
+		}
+		p.consume("in")
+		p.skip()
+		return p.parseRValue()
+	}
+	p.cursor = saved
+
+	return nil
+}
+
+// parseParenthesisCondition parse the condition after opened parenthesis
+func (p *logstashConfigParser) parseParenthesisCondition() error {
+	p.skip()
+	if err := p.parseCondition(); err != nil {
+		return err
+	}
+	p.skip()
+	return p.expect(")", "to close condition")
+}
+
+// parseRValue parse the value used in condition
+func (p *logstashConfigParser) parseRValue() error {
+	var err error
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		_, err = p.parseString()
+	case c == '-' || isLogstashConfigDigit(c):
+		_, err = p.parseNumber()
+	case c == '[':
+		if !p.parseSelector() {
+			_, err = p.parseArray()
+		}
+	case c == '/':
+		err = p.parseRegexp()
+	case isLogstashConfigNameChar(c):
+		err = p.parseMethodCall()
+	default:
+		err = p.errorf(p.cursor, "expected value in condition, found %s", p.describe(p.cursor, ""))
+	}
+	return err
+}
+
+// parseSelector parse field reference like [foo][bar]. It return false and not move when it is not a field reference
+func (p *logstashConfigParser) parseSelector() bool {
+	saved := p.cursor
+	found := false
+	for p.peek() == '[' {
+		p.next()
+		begin := p.cursor.offset
+		for !p.eof() && p.peek() != ']' && p.peek() != '[' && p.peek() != ',' {
+			p.next()
+		}
+		if p.peek() != ']' || p.cursor.offset == begin {
+			p.cursor = saved
+			return false
+		}
+		p.next()
+		found = true
+	}
+	return found
+}
+
+// parseRegexp parse regexp like /^foo/
+func (p *logstashConfigParser) parseRegexp() error {
+	start := p.cursor
+	p.next()
+	for {
+		if p.eof() {
+			return p.errorf(start, "unterminated regexp")
+		}
+		c := p.next()
+		if c == '\\' && !p.eof() {
+			p.next()
+			continue
+		}
+		if c == '/' {
+			return nil
+		}
+	}
+}
+
+// parseMethodCall parse method call like foo([bar], "baz")
+func (p *logstashConfigParser) parseMethodCall() error {
+	start := p.cursor
+	name := p.readName()
+	p.skip()
+	if !p.consume("(") {
+		return p.errorf(start, "expected value in condition, found %s", p.describe(start, name))
+	}
+	p.skip()
+	if p.consume(")") {
+		return nil
+	}
+	for {
+		p.skip()
+		if err := p.parseRValue(); err != nil {
+			return err
+		}
+		p.skip()
+		if p.consume(",") {
+			continue
+		}
+		return p.expect(")", "to close method "+name)
+	}
+}
+
+// parseName parse the name or quoted string used as plugin name, setting name or hash key
+func (p *logstashConfigParser) parseName(expected string) (string, error) {
+	if c := p.peek(); c == '"' || c == '\'' {
+		return p.parseString()
+	}
+	start := p.cursor
+	name := p.readName()
+	if name == "" {
+		return "", p.errorf(start, "expected %s, found %s", expected, p.describe(start, ""))
+	}
+	return name, nil
+}
+
+// readName read the characters allowed in names
+func (p *logstashConfigParser) readName() string {
+	begin := p.cursor.offset
+	for isLogstashConfigNameChar(p.peek()) {
+		p.next()
+	}
+	return string(p.input[begin:p.cursor.offset])
+}
+
+// skip move after spaces and comments
+func (p *logstashConfigParser) skip() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+// expect consume the token or return error
+func (p *logstashConfigParser) expect(token string, context string) error {
+	if !p.consume(token) {
+		return p.errorf(p.cursor, "expected %s %s, found %s", token, context, p.describe(p.cursor, ""))
+	}
+	return nil
+}
+
+// consume move after the token if the input start with it
+func (p *logstashConfigParser) consume(token string) bool {
+	if !p.hasPrefix(token) {
+		return false
+	}
+	for range token {
+		p.next()
+	}
+	return true
+}
+
+// hasPrefix return true if the input start with token
+func (p *logstashConfigParser) hasPrefix(token string) bool {
+	offset := p.cursor.offset
+	for _, c := range token {
+		if offset >= len(p.input) || p.input[offset] != c {
+			return false
+		}
+		offset++
+	}
+	return true
+}
+
+// hasKeyword return true if the input start with the keyword followed by non name character
+func (p *logstashConfigParser) hasKeyword(keyword string) bool {
+	if !p.hasPrefix(keyword) {
+		return false
+	}
+	offset := p.cursor.offset + len([]rune(keyword))
+	return offset >= len(p.input) || !isLogstashConfigNameChar(p.input[offset])
+}
+
+// next return the current character and move to the next
+func (p *logstashConfigParser) next() rune {
+	c := p.input[p.cursor.offset]
+	p.cursor.offset++
+	if c == '\n' {
+		p.cursor.line++
+		p.cursor.column = 1
+	} else {
+		p.cursor.column++
+	}
+	return c
+}
+
+// peek return the current character, or 0 at the end
+func (p *logstashConfigParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.cursor.offset]
+}
+
+func (p *logstashConfigParser) eof() bool {
+	return p.cursor.offset >= len(p.input)
+}
+
+// describe return the token found at cursor for error message
+func (p *logstashConfigParser) describe(cursor logstashConfigCursor, token string) string {
+	if token != "" {
+		return strconv.Quote(token)
+	}
+	if cursor.offset >= len(p.input) {
+		return "end of pipeline"
+	}
+	return strconv.QuoteRune(p.input[cursor.offset])
+}
+
+func (p *logstashConfigParser) errorf(cursor logstashConfigCursor, format string, params ...interface{}) error {
+	return &LogstashPipelineConfigError{
+		Line:    cursor.line,
+		Column:  cursor.column,
+		Message: fmt.Sprintf(format, params...),
+	}
+}
+
+func isLogstashConfigDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLogstashConfigNameChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isLogstashConfigDigit(c) || c == '_' || c == '-'
+}
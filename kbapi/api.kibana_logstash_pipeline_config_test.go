@@ -0,0 +1,83 @@
+package kbapi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogstashPipelineConfig(t *testing.T) {
+
+	// Valid pipeline
+	config, err := ParseLogstashPipelineConfig(`
+# Read from beats
+input {
+  beats { port => 5044 ssl => false }
+}
+
+filter {
+  if [type] == "syslog" and [host][name] !~ /^test/ {
+    grok {
+      match => { "message" => "%{SYSLOGLINE}" }
+      tag_on_failure => ["_grokparsefailure", 'syslog']
+    }
+  } else if [type] in ["nginx", "apache"] {
+    mutate { add_field => { "web" => "true" } }
+  } else if !("debug" in [tags]) {
+    drop {}
+  } else {
+    date { match => [ "timestamp", "ISO8601" ] }
+  }
+}
+
+output {
+  elasticsearch {
+    hosts => ["http://es:9200"]
+    codec => json { charset => "UTF-8" }
+    retry_max_interval => -1.5
+  }
+}
+`)
+	assert.NoError(t, err)
+	assert.Len(t, config.Sections, 3)
+	assert.Equal(t, LogstashPipelineSectionInput, config.Sections[0].Type)
+	assert.Equal(t, 3, config.Sections[0].Line)
+	assert.Equal(t, "beats", config.Sections[0].Plugins[0].Name)
+	assert.Equal(t, []LogstashPipelineAttribute{{Name: "port", Value: float64(5044)}, {Name: "ssl", Value: "false"}}, config.Sections[0].Plugins[0].Attributes)
+	filters := config.Sections[1].Plugins
+	assert.Len(t, filters, 4)
+	assert.Equal(t, "grok", filters[0].Name)
+	assert.Equal(t, 9, filters[0].Line)
+	assert.Equal(t, 5, filters[0].Column)
+	assert.Equal(t, map[string]interface{}{"message": "%{SYSLOGLINE}"}, filters[0].Attributes[0].Value)
+	assert.Equal(t, []interface{}{"_grokparsefailure", "syslog"}, filters[0].Attributes[1].Value)
+	codec := config.Sections[2].Plugins[0].Attributes[1].Value.(*LogstashPipelinePlugin)
+	assert.Equal(t, "json", codec.Name)
+	assert.Equal(t, -1.5, config.Sections[2].Plugins[0].Attributes[2].Value)
+
+	// Invalid pipelines
+	testCases := []struct {
+		config string
+		line   int
+		column int
+	}{
+		{config: "", line: 1, column: 1},
+		{config: "inputs { stdin {} }", line: 1, column: 1},
+		{config: "input {\n  stdin {}\n", line: 3, column: 1},
+		{config: "input { stdin { codec => } }", line: 1, column: 26},
+		{config: "input {\n  file { path => \"/var/log }\n}", line: 2, column: 18},
+		{config: "filter {\n  if [type] = \"a\" { drop {} }\n}", line: 2, column: 13},
+		{config: "output { stdout { codec => rubydebug }", line: 1, column: 39},
+		{config: "filter { mutate { add_field => { \"a\" \"b\" } } }", line: 1, column: 38},
+		{config: "output { stdout {} } else", line: 1, column: 22},
+	}
+	for _, testCase := range testCases {
+		_, err = ParseLogstashPipelineConfig(testCase.config)
+		configErr := &LogstashPipelineConfigError{}
+		if assert.Error(t, err, testCase.config) && assert.True(t, errors.As(err, &configErr), testCase.config) {
+			assert.Equal(t, testCase.line, configErr.Line, testCase.config)
+			assert.Equal(t, testCase.column, configErr.Column, testCase.config)
+		}
+	}
+}
@@ -1,6 +1,13 @@
 package kbapi
 
 import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -11,15 +18,23 @@ func (s *KBAPITestSuite) TestKibanaLogstashPipeline() {
 		ID:          "test",
 		Description: "Acceptance test",
 		Pipeline:    "input { stdin {} } output { stdout {} }",
-		Settings: map[string]interface{}{
-			"queue.type": "persisted",
+		Settings: &LogstashPipelineSettings{
+			QueueType: LogstashPipelineQueuePersisted,
 		},
 	}
-	logstashPipeline, err := s.API.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline)
+	logstashPipeline, created, err := s.API.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline, nil)
 	assert.NoError(s.T(), err)
+	assert.True(s.T(), created)
 	assert.NotNil(s.T(), logstashPipeline)
 	assert.Equal(s.T(), "test", logstashPipeline.ID)
 
+	// Update logstash pipeline
+	logstashPipeline.Description = "Acceptance test updated"
+	logstashPipeline, created, err = s.API.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline, nil)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), created)
+	assert.Equal(s.T(), "Acceptance test updated", logstashPipeline.Description)
+
 	// Get logstash pipeline
 	logstashPipeline, err = s.API.KibanaLogstashPipeline.Get("test")
 	assert.NoError(s.T(), err)
@@ -38,4 +53,181 @@ func (s *KBAPITestSuite) TestKibanaLogstashPipeline() {
 	assert.NoError(s.T(), err)
 	assert.Nil(s.T(), logstashPipeline)
 
+	// Bulk delete logstash pipelines
+	for _, id := range []string{"test1", "test2"} {
+		_, _, err = s.API.KibanaLogstashPipeline.CreateOrUpdate(&LogstashPipeline{
+			ID:       id,
+			Pipeline: "input { stdin {} } output { stdout {} }",
+		}, nil)
+		assert.NoError(s.T(), err)
+	}
+	bulkDeleteResult, err := s.API.KibanaLogstashPipeline.BulkDelete([]string{"test1", "test2"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, bulkDeleteResult.NumSuccesses)
+	assert.Equal(s.T(), []string{"test1", "test2"}, bulkDeleteResult.Successes)
+
+}
+
+func TestLogstashPipelineSettingsExtra(t *testing.T) {
+
+	// Unknown settings are kept on Extra
+	settings := &LogstashPipelineSettings{}
+	err := json.Unmarshal([]byte(`{"pipeline.workers":2,"queue.page_capacity":"64mb","pipeline.ecs_compatibility":"v8"}`), settings)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *settings.PipelineWorkers)
+	assert.Equal(t, map[string]json.RawMessage{
+		"queue.page_capacity":        json.RawMessage(`"64mb"`),
+		"pipeline.ecs_compatibility": json.RawMessage(`"v8"`),
+	}, settings.Extra)
+
+	// Extra settings are sent back
+	b, err := json.Marshal(&LogstashPipelineRequest{Settings: settings})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"settings":{"pipeline.workers":2,"queue.page_capacity":"64mb","pipeline.ecs_compatibility":"v8"}}`, string(b))
+
+	// Known settings win over extra settings
+	settings.Extra["pipeline.workers"] = json.RawMessage(`8`)
+	b, err = json.Marshal(settings)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"pipeline.workers":2`)
+}
+
+func TestLogstashPipelineSettingsValidate(t *testing.T) {
+
+	workers := 4
+	zero := 0
+	settings := &LogstashPipelineSettings{
+		PipelineWorkers:       &workers,
+		PipelineBatchDelay:    &zero,
+		QueueType:             LogstashPipelineQueuePersisted,
+		QueueMaxBytes:         "1gb",
+		QueueCheckpointWrites: &zero,
+	}
+	assert.NoError(t, settings.Validate())
+
+	assert.Error(t, (&LogstashPipelineSettings{PipelineWorkers: &zero}).Validate())
+	assert.Error(t, (&LogstashPipelineSettings{PipelineBatchSize: &zero}).Validate())
+	assert.Error(t, (&LogstashPipelineSettings{QueueType: "disk"}).Validate())
+	assert.Error(t, (&LogstashPipelineSettings{QueueMaxBytes: "1024"}).Validate())
+	assert.Error(t, (&LogstashPipelineSettings{QueueMaxBytes: "1 gb"}).Validate())
+
+	// Invalid settings are not sent to Kibana
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+	_, _, err := api.KibanaLogstashPipeline.CreateOrUpdate(&LogstashPipeline{
+		ID:       "test",
+		Pipeline: "input { stdin {} } output { stdout {} }",
+		Settings: &LogstashPipelineSettings{QueueType: "disk"},
+	}, nil)
+	assert.Error(t, err)
+	assert.False(t, called)
+}
+
+func TestKibanaLogstashPipelineCreateOrUpdate(t *testing.T) {
+
+	var payload []byte
+	var stored []byte
+	gets := 0
+	lostAfterWrite := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			gets++
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(stored)
+		case http.MethodPut:
+			payload, _ = io.ReadAll(r.Body)
+			if lostAfterWrite {
+				stored = nil
+			} else {
+				stored = []byte(`{"id":"test","pipeline":"input { stdin {} } output { stdout {} }","username":"elastic"}`)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+
+	logstashPipeline := &LogstashPipeline{
+		ID:          "test",
+		Description: "test",
+		Pipeline:    "input { stdin {} } output { stdout {} }",
+		Username:    "elastic",
+	}
+
+	// Create send the full request and fetch the pipeline
+	result, created, err := api.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline, nil)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.JSONEq(t, `{"description":"test","pipeline":"input { stdin {} } output { stdout {} }","username":"elastic"}`, string(payload))
+	assert.Equal(t, "elastic", result.Username)
+	assert.Equal(t, 2, gets)
+
+	// Update get the pipeline before and after the write
+	gets = 0
+	_, created, err = api.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline, nil)
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, 2, gets)
+
+	// Update without fetch only write
+	gets = 0
+	stored = nil
+	result, created, err = api.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline, &OptionalCreateOrUpdatePipelineParameters{SkipFetch: true})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, logstashPipeline, result)
+	assert.NotSame(t, logstashPipeline, result)
+	assert.Equal(t, 0, gets)
+
+	// Pipeline not found after write
+	lostAfterWrite = true
+	_, _, err = api.KibanaLogstashPipeline.CreateOrUpdate(logstashPipeline, nil)
+	assert.Error(t, err)
+	apiErr := APIError{}
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, 404, apiErr.Code)
+		assert.Contains(t, apiErr.Message, "test")
+	}
+
+	// ID is mandatory
+	_, _, err = api.KibanaLogstashPipeline.CreateOrUpdate(&LogstashPipeline{}, nil)
+	assert.Error(t, err)
+}
+
+func TestKibanaLogstashPipelineBulkDelete(t *testing.T) {
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/logstash/pipelines/delete":
+			body, _ = io.ReadAll(r.Body)
+			_, _ = w.Write([]byte(`{"numSuccesses":1,"numErrors":1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/logstash/pipeline/locked":
+			_, _ = w.Write([]byte(`{"id":"locked","pipeline":"input { stdin {} } output { stdout {} }"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+
+	result, err := api.KibanaLogstashPipeline.BulkDelete([]string{"deleted", "locked"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"pipelineIds":["deleted","locked"]}`, string(body))
+	assert.Equal(t, 1, result.NumSuccesses)
+	assert.Equal(t, 1, result.NumErrors)
+	assert.Equal(t, []string{"deleted"}, result.Successes)
+	assert.Equal(t, []string{"locked"}, result.Errors)
+
+	// IDs are mandatory
+	_, err = api.KibanaLogstashPipeline.BulkDelete(nil)
+	assert.Error(t, err)
 }
@@ -1,6 +1,7 @@
 package kbapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -13,12 +14,16 @@ const (
 )
 
 // KibanaRole is the API role object
+// TransformErrors and UnrecognizedApplications are returned by Kibana and never sent back
 type KibanaRole struct {
-	Name            string                       `json:"name,omitempty"`
-	Metadata        map[string]interface{}       `json:"metadata,omitempty"`
-	TransientMedata *KibanaRoleTransientMetadata `json:"transient_metadata,omitempty"`
-	Elasticsearch   *KibanaRoleElasticsearch     `json:"elasticsearch,omitempty"`
-	Kibana          []KibanaRoleKibana           `json:"kibana,omitempty"`
+	Name                     string                       `json:"name,omitempty"`
+	Description              string                       `json:"description,omitempty"`
+	Metadata                 map[string]interface{}       `json:"metadata,omitempty"`
+	TransientMedata          *KibanaRoleTransientMetadata `json:"transient_metadata,omitempty"`
+	Elasticsearch            *KibanaRoleElasticsearch     `json:"elasticsearch,omitempty"`
+	Kibana                   []KibanaRoleKibana           `json:"kibana,omitempty"`
+	TransformErrors          []string                     `json:"_transform_error,omitempty"`
+	UnrecognizedApplications []string                     `json:"_unrecognized_applications,omitempty"`
 }
 
 // KibanaRoleTransientMetadata is the API TransientMedata object
@@ -28,26 +33,57 @@ type KibanaRoleTransientMetadata struct {
 
 // KibanaRoleElasticsearch is the API Elasticsearch object
 type KibanaRoleElasticsearch struct {
-	Indices []KibanaRoleElasticsearchIndice `json:"indices,omitempty"`
-	Cluster []string                        `json:"cluster,omitempty"`
-	RunAs   []string                        `json:"run_as,omitempty"`
+	Indices       []KibanaRoleElasticsearchIndice        `json:"indices,omitempty"`
+	RemoteIndices []KibanaRoleElasticsearchRemoteIndice  `json:"remote_indices,omitempty"`
+	Cluster       []string                               `json:"cluster,omitempty"`
+	RemoteCluster []KibanaRoleElasticsearchRemoteCluster `json:"remote_cluster,omitempty"`
+	RunAs         []string                               `json:"run_as,omitempty"`
 }
 
 // KibanaRoleKibana is the API Kibana object
+// Reserved is returned by Kibana for reserved privileges and never sent back
 type KibanaRoleKibana struct {
-	Base    []string            `json:"base,omitempty"`
-	Feature map[string][]string `json:"feature,omitempty"`
-	Spaces  []string            `json:"spaces,omitempty"`
+	Base     []string            `json:"base,omitempty"`
+	Feature  map[string][]string `json:"feature,omitempty"`
+	Spaces   []string            `json:"spaces,omitempty"`
+	Reserved []string            `json:"_reserved,omitempty"`
 }
 
 // KibanaRoleElasticsearchIndice is the API indice object
 type KibanaRoleElasticsearchIndice struct {
-	Names         []string               `json:"names,omitempty"`
-	Privileges    []string               `json:"privileges,omitempty"`
-	FieldSecurity map[string]interface{} `json:"field_security,omitempty"`
-	Query         interface{}            `json:"query,omitempty"`
+	Names                  []string                 `json:"names,omitempty"`
+	Privileges             []string                 `json:"privileges,omitempty"`
+	FieldSecurity          *KibanaRoleFieldSecurity `json:"field_security,omitempty"`
+	Query                  KibanaRoleQuery          `json:"query,omitempty"`
+	AllowRestrictedIndices bool                     `json:"allow_restricted_indices,omitempty"`
 }
 
+// KibanaRoleElasticsearchRemoteIndice is the API remote indice object
+type KibanaRoleElasticsearchRemoteIndice struct {
+	Clusters               []string                 `json:"clusters"`
+	Names                  []string                 `json:"names,omitempty"`
+	Privileges             []string                 `json:"privileges,omitempty"`
+	FieldSecurity          *KibanaRoleFieldSecurity `json:"field_security,omitempty"`
+	Query                  KibanaRoleQuery          `json:"query,omitempty"`
+	AllowRestrictedIndices bool                     `json:"allow_restricted_indices,omitempty"`
+}
+
+// KibanaRoleElasticsearchRemoteCluster is the API remote cluster privileges object
+type KibanaRoleElasticsearchRemoteCluster struct {
+	Clusters   []string `json:"clusters"`
+	Privileges []string `json:"privileges"`
+}
+
+// KibanaRoleFieldSecurity is the API field level security object
+type KibanaRoleFieldSecurity struct {
+	Grant  []string `json:"grant,omitempty"`
+	Except []string `json:"except,omitempty"`
+}
+
+// KibanaRoleQuery is the document level security query.
+// Kibana expect the query as JSON string, but it can be read from string or from JSON object
+type KibanaRoleQuery string
+
 // KibanaRoles is a list of role object
 type KibanaRoles []KibanaRole
 
@@ -60,6 +96,12 @@ type KibanaRoleManagementList func() (KibanaRoles, error)
 // KibanaRoleManagementCreateOrUpdate permit to create or update role in Kibana
 type KibanaRoleManagementCreateOrUpdate func(kibanaRole *KibanaRole) (*KibanaRole, error)
 
+// KibanaRoleManagementCreate permit to create role in Kibana. It failed with ConflictError if role already exist
+type KibanaRoleManagementCreate func(kibanaRole *KibanaRole) (*KibanaRole, error)
+
+// KibanaRoleManagementUpdate permit to update existing role in Kibana
+type KibanaRoleManagementUpdate func(kibanaRole *KibanaRole) (*KibanaRole, error)
+
 // KibanaRoleManagementDelete permit to delete role in Kibana
 type KibanaRoleManagementDelete func(name string) error
 
@@ -69,6 +111,78 @@ func (k *KibanaRole) String() string {
 	return string(json)
 }
 
+// IsReserved return true if the role is a built-in role that can't be modified
+func (k *KibanaRole) IsReserved() bool {
+	reserved, _ := k.Metadata["_reserved"].(bool)
+	return reserved
+}
+
+// IsDeprecated return true if the role is deprecated
+func (k *KibanaRole) IsDeprecated() bool {
+	deprecated, _ := k.Metadata["_deprecated"].(bool)
+	return deprecated
+}
+
+// DeprecatedReason return why the role is deprecated
+func (k *KibanaRole) DeprecatedReason() string {
+	reason, _ := k.Metadata["_deprecated_reason"].(string)
+	return reason
+}
+
+// NewKibanaRoleQuery create query from string or from object that can be converted to JSON
+func NewKibanaRoleQuery(query interface{}) (KibanaRoleQuery, error) {
+	switch q := query.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return KibanaRoleQuery(q), nil
+	case KibanaRoleQuery:
+		return q, nil
+	default:
+		b, err := json.Marshal(q)
+		if err != nil {
+			return "", err
+		}
+		return KibanaRoleQuery(b), nil
+	}
+}
+
+// Map return the query as JSON object
+func (q KibanaRoleQuery) Map() (map[string]interface{}, error) {
+	if q == "" {
+		return nil, nil
+	}
+	query := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(q), &query); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// UnmarshalJSON permit to read query from JSON string or from JSON object
+func (q *KibanaRoleQuery) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		*q = ""
+		return nil
+	}
+	if data[0] == '"' {
+		var query string
+		if err := json.Unmarshal(data, &query); err != nil {
+			return err
+		}
+		*q = KibanaRoleQuery(query)
+		return nil
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := json.Compact(buffer, data); err != nil {
+		return err
+	}
+	*q = KibanaRoleQuery(buffer.String())
+	return nil
+}
+
 // newKibanaRoleManagementGetFunc permit to get the kibana role with it name
 func newKibanaRoleManagementGetFunc(c *resty.Client) KibanaRoleManagementGet {
 	return func(name string) (*KibanaRole, error) {
@@ -126,43 +240,107 @@ func newKibanaRoleManagementListFunc(c *resty.Client) KibanaRoleManagementList {
 
 }
 
-// newKibanaRoleManagementGetFunc permit to create or update the kibana role
+// newKibanaRoleManagementCreateOrUpdateFunc permit to create or update the kibana role
 func newKibanaRoleManagementCreateOrUpdateFunc(c *resty.Client) KibanaRoleManagementCreateOrUpdate {
+	return func(kibanaRole *KibanaRole) (*KibanaRole, error) {
+		return putKibanaRole(c, kibanaRole, false)
+	}
+
+}
+
+// newKibanaRoleManagementCreateFunc permit to create the kibana role only if it not already exist
+func newKibanaRoleManagementCreateFunc(c *resty.Client) KibanaRoleManagementCreate {
+	return func(kibanaRole *KibanaRole) (*KibanaRole, error) {
+		return putKibanaRole(c, kibanaRole, true)
+	}
+
+}
+
+// newKibanaRoleManagementUpdateFunc permit to update the kibana role only if it already exist
+func newKibanaRoleManagementUpdateFunc(c *resty.Client) KibanaRoleManagementUpdate {
 	return func(kibanaRole *KibanaRole) (*KibanaRole, error) {
 
 		if kibanaRole == nil {
 			return nil, NewAPIError(600, "You must provide kibana role object")
 		}
-		log.Debug("Kibana role: ", kibanaRole)
-		roleName := kibanaRole.Name
-
-		path := fmt.Sprintf("%s/%s", basePathKibanaRoleManagement, roleName)
-		kibanaRole.Name = ""
-		jsonData, err := json.Marshal(kibanaRole)
-		log.Debugf("Payload: %s", jsonData)
-		if err != nil {
-			return nil, err
+		if kibanaRole.Name == "" {
+			return nil, NewAPIError(600, "You must provide kibana role name")
 		}
-		resp, err := c.R().SetBody(jsonData).Put(path)
+
+		currentRole, err := newKibanaRoleManagementGetFunc(c)(kibanaRole.Name)
 		if err != nil {
 			return nil, err
 		}
-		log.Debug("Response: ", resp)
-		if resp.StatusCode() >= 300 {
-			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		if currentRole == nil {
+			return nil, NewAPIError(404, "Kibana role %s not found", kibanaRole.Name)
 		}
 
-		// Retrive the object to return it
-		kibanaRole, err = newKibanaRoleManagementGetFunc(c)(roleName)
-		if err != nil {
-			return nil, err
+		return putKibanaRole(c, kibanaRole, false)
+	}
+
+}
+
+// putKibanaRole send the role to Kibana without modify the caller object and return the role stored by Kibana
+// When createOnly is true, Kibana reject the request if role already exist
+func putKibanaRole(c *resty.Client, kibanaRole *KibanaRole, createOnly bool) (*KibanaRole, error) {
+
+	if kibanaRole == nil {
+		return nil, NewAPIError(600, "You must provide kibana role object")
+	}
+	if kibanaRole.Name == "" {
+		return nil, NewAPIError(600, "You must provide kibana role name")
+	}
+	log.Debug("Kibana role: ", kibanaRole)
+	log.Debug("CreateOnly: ", createOnly)
+	roleName := kibanaRole.Name
+
+	// The name is on URL, not on payload, and read only fields are rejected by Kibana
+	payload := *kibanaRole
+	payload.Name = ""
+	payload.TransformErrors = nil
+	payload.UnrecognizedApplications = nil
+	if kibanaRole.Kibana != nil {
+		payload.Kibana = make([]KibanaRoleKibana, 0, len(kibanaRole.Kibana))
+		for _, kibana := range kibanaRole.Kibana {
+			kibana.Reserved = nil
+			payload.Kibana = append(payload.Kibana, kibana)
 		}
+	}
 
-		log.Debug("KibanaRole: ", kibanaRole)
+	path := fmt.Sprintf("%s/%s", basePathKibanaRoleManagement, roleName)
+	jsonData, err := json.Marshal(payload)
+	log.Debugf("Payload: %s", jsonData)
+	if err != nil {
+		return nil, err
+	}
+	request := c.R().SetBody(jsonData)
+	if createOnly {
+		request = request.SetQueryParam("createOnly", "true")
+	}
+	resp, err := request.Put(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Response: ", resp)
+	if resp.StatusCode() >= 300 {
+		if resp.StatusCode() == 409 {
+			return nil, NewConflictError([]ConflictObject{{Type: "role", ID: roleName}}, "Kibana role %s already exist", roleName)
+		}
+		return nil, NewAPIError(resp.StatusCode(), resp.Status())
+	}
 
-		return kibanaRole, nil
+	// Retrive the object to return it
+	kibanaRole, err = newKibanaRoleManagementGetFunc(c)(roleName)
+	if err != nil {
+		return nil, err
 	}
+	if kibanaRole == nil {
+		return nil, NewAPIError(404, "Kibana role %s not found", roleName)
+	}
+
+	log.Debug("KibanaRole: ", kibanaRole)
 
+	return kibanaRole, nil
 }
 
 // newKibanaRoleManagementDeleteFunc permit to delete kibana role with it name
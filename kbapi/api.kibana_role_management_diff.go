@@ -0,0 +1,219 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind of change between the desired role and the actual role
+const (
+	KibanaRoleChangeAdded    = "added"
+	KibanaRoleChangeRemoved  = "removed"
+	KibanaRoleChangeModified = "modified"
+)
+
+// KibanaRoleChange is one difference between the desired role and the actual role
+// Desired and Actual are the JSON values, empty when the path not exist on the role
+type KibanaRoleChange struct {
+	Kind    string
+	Path    string
+	Desired string
+	Actual  string
+}
+
+// KibanaRoleDiff is the list of differences between the desired role and the actual role
+type KibanaRoleDiff struct {
+	Name    string
+	Changes []KibanaRoleChange
+}
+
+// HasChanges return true if the actual role drift from the desired role
+func (d *KibanaRoleDiff) HasChanges() bool {
+	return len(d.Changes) > 0
+}
+
+// String return the differences as human readable text, one change by line
+func (d *KibanaRoleDiff) String() string {
+	if !d.HasChanges() {
+		return fmt.Sprintf("Role %s: no changes", d.Name)
+	}
+
+	lines := make([]string, 0, len(d.Changes)+1)
+	lines = append(lines, fmt.Sprintf("Role %s: %d changes", d.Name, len(d.Changes)))
+	for _, change := range d.Changes {
+		switch change.Kind {
+		case KibanaRoleChangeAdded:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", change.Path, change.Desired))
+		case KibanaRoleChangeRemoved:
+			lines = append(lines, fmt.Sprintf("- %s: %s", change.Path, change.Actual))
+		default:
+			lines = append(lines, fmt.Sprintf("~ %s: %s => %s", change.Path, change.Actual, change.Desired))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// DiffKibanaRole compare the desired role with the actual role returned by Kibana.
+// The order of privileges, indices, spaces and features is ignored, like the fields populated by Kibana
+// (metadata starting with _, transient metadata, _reserved, _transform_error and _unrecognized_applications).
+// Added changes are on desired role only, removed changes are on actual role only.
+func DiffKibanaRole(desired *KibanaRole, actual *KibanaRole) *KibanaRoleDiff {
+	diff := &KibanaRoleDiff{}
+	if desired != nil {
+		diff.Name = desired.Name
+	} else if actual != nil {
+		diff.Name = actual.Name
+	}
+
+	desiredFields := flattenKibanaRole(desired)
+	actualFields := flattenKibanaRole(actual)
+
+	paths := make([]string, 0, len(desiredFields)+len(actualFields))
+	for path := range desiredFields {
+		paths = append(paths, path)
+	}
+	for path := range actualFields {
+		if _, ok := desiredFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		desiredValue, inDesired := desiredFields[path]
+		actualValue, inActual := actualFields[path]
+		switch {
+		case inDesired && !inActual:
+			diff.Changes = append(diff.Changes, KibanaRoleChange{Kind: KibanaRoleChangeAdded, Path: path, Desired: desiredValue})
+		case !inDesired && inActual:
+			diff.Changes = append(diff.Changes, KibanaRoleChange{Kind: KibanaRoleChangeRemoved, Path: path, Actual: actualValue})
+		case desiredValue != actualValue:
+			diff.Changes = append(diff.Changes, KibanaRoleChange{Kind: KibanaRoleChangeModified, Path: path, Desired: desiredValue, Actual: actualValue})
+		}
+	}
+
+	return diff
+}
+
+// flattenKibanaRole convert the role to a map of path and normalized JSON value, without empty values
+func flattenKibanaRole(kibanaRole *KibanaRole) map[string]string {
+	fields := make(map[string]string)
+	if kibanaRole == nil {
+		return fields
+	}
+
+	addRoleField(fields, "description", kibanaRole.Description)
+	for key, value := range kibanaRole.Metadata {
+		if !strings.HasPrefix(key, "_") {
+			addRoleField(fields, fmt.Sprintf("metadata.%s", key), value)
+		}
+	}
+
+	if kibanaRole.Elasticsearch != nil {
+		addRoleField(fields, "elasticsearch.cluster", sortedStrings(kibanaRole.Elasticsearch.Cluster))
+		addRoleField(fields, "elasticsearch.run_as", sortedStrings(kibanaRole.Elasticsearch.RunAs))
+
+		// Each collection has its own keys, so a key used on two collections is not suffixed
+		indicesKeys := make(map[string]int)
+		for _, indice := range kibanaRole.Elasticsearch.Indices {
+			path := fmt.Sprintf("elasticsearch.indices[%s]", uniqueRoleKey(indicesKeys, strings.Join(sortedStrings(indice.Names), ",")))
+			addRoleIndiceFields(fields, path, indice.Privileges, indice.FieldSecurity, indice.Query, indice.AllowRestrictedIndices)
+		}
+		remoteIndicesKeys := make(map[string]int)
+		for _, indice := range kibanaRole.Elasticsearch.RemoteIndices {
+			key := fmt.Sprintf("%s:%s", strings.Join(sortedStrings(indice.Clusters), ","), strings.Join(sortedStrings(indice.Names), ","))
+			path := fmt.Sprintf("elasticsearch.remote_indices[%s]", uniqueRoleKey(remoteIndicesKeys, key))
+			addRoleIndiceFields(fields, path, indice.Privileges, indice.FieldSecurity, indice.Query, indice.AllowRestrictedIndices)
+		}
+		remoteClusterKeys := make(map[string]int)
+		for _, remoteCluster := range kibanaRole.Elasticsearch.RemoteCluster {
+			path := fmt.Sprintf("elasticsearch.remote_cluster[%s]", uniqueRoleKey(remoteClusterKeys, strings.Join(sortedStrings(remoteCluster.Clusters), ",")))
+			addRoleField(fields, fmt.Sprintf("%s.privileges", path), sortedStrings(remoteCluster.Privileges))
+		}
+	}
+
+	keys := make(map[string]int)
+	for _, kibana := range kibanaRole.Kibana {
+		// Kibana grant privileges on all spaces when no space is provided
+		spaces := sortedStrings(kibana.Spaces)
+		if len(spaces) == 0 {
+			spaces = []string{"*"}
+		}
+		path := fmt.Sprintf("kibana[%s]", uniqueRoleKey(keys, strings.Join(spaces, ",")))
+		addRoleField(fields, fmt.Sprintf("%s.base", path), sortedStrings(kibana.Base))
+		for feature, privileges := range kibana.Feature {
+			addRoleField(fields, fmt.Sprintf("%s.feature.%s", path, feature), sortedStrings(privileges))
+		}
+	}
+
+	return fields
+}
+
+// addRoleIndiceFields add the fields of indice or remote indice
+func addRoleIndiceFields(fields map[string]string, path string, privileges []string, fieldSecurity *KibanaRoleFieldSecurity, query KibanaRoleQuery, allowRestrictedIndices bool) {
+	// Keep indice without privileges on diff
+	fields[path] = "{}"
+	addRoleField(fields, fmt.Sprintf("%s.privileges", path), sortedStrings(privileges))
+	if fieldSecurity != nil {
+		addRoleField(fields, fmt.Sprintf("%s.field_security.grant", path), sortedStrings(fieldSecurity.Grant))
+		addRoleField(fields, fmt.Sprintf("%s.field_security.except", path), sortedStrings(fieldSecurity.Except))
+	}
+	if query != "" {
+		// Normalize the query to not see difference on spaces or keys order
+		if queryMap, err := query.Map(); err == nil {
+			addRoleField(fields, fmt.Sprintf("%s.query", path), queryMap)
+		} else {
+			addRoleField(fields, fmt.Sprintf("%s.query", path), string(query))
+		}
+	}
+	addRoleField(fields, fmt.Sprintf("%s.allow_restricted_indices", path), allowRestrictedIndices)
+}
+
+// addRoleField add the value as JSON if not empty
+func addRoleField(fields map[string]string, path string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case string:
+		if v == "" {
+			return
+		}
+	case bool:
+		if !v {
+			return
+		}
+	case []string:
+		if len(v) == 0 {
+			return
+		}
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%v", value))
+	}
+	fields[path] = string(b)
+}
+
+// uniqueRoleKey return key, suffixed by its position when the same key is used many times
+func uniqueRoleKey(keys map[string]int, key string) string {
+	keys[key]++
+	if keys[key] > 1 {
+		return fmt.Sprintf("%s#%d", key, keys[key])
+	}
+	return key
+}
+
+// sortedStrings return sorted copy of list
+func sortedStrings(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	sorted := make([]string, len(list))
+	copy(sorted, list)
+	sort.Strings(sorted)
+	return sorted
+}
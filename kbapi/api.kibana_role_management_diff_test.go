@@ -0,0 +1,196 @@
+package kbapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *KBAPITestSuite) TestKibanaRoleDiff() {
+
+	desired := &KibanaRole{
+		Name: "test-diff",
+		Elasticsearch: &KibanaRoleElasticsearch{
+			Cluster: []string{"monitor", "manage_index_templates"},
+			Indices: []KibanaRoleElasticsearchIndice{
+				{
+					Names:      []string{"logs-*", "metrics-*"},
+					Privileges: []string{"view_index_metadata", "read"},
+				},
+			},
+		},
+		Kibana: []KibanaRoleKibana{
+			{
+				Feature: map[string][]string{
+					"discover":  {"read"},
+					"dashboard": {"read"},
+				},
+				Spaces: []string{"test", "default"},
+			},
+		},
+	}
+	_, err := s.API.KibanaRoleManagement.CreateOrUpdate(desired)
+	assert.NoError(s.T(), err)
+
+	// Role just created has no drift
+	actual, err := s.API.KibanaRoleManagement.Get("test-diff")
+	assert.NoError(s.T(), err)
+	diff := DiffKibanaRole(desired, actual)
+	assert.False(s.T(), diff.HasChanges(), diff.String())
+
+	// Role modified on UI
+	actual.Kibana[0].Feature["dashboard"] = []string{"all"}
+	_, err = s.API.KibanaRoleManagement.Update(actual)
+	assert.NoError(s.T(), err)
+	actual, err = s.API.KibanaRoleManagement.Get("test-diff")
+	assert.NoError(s.T(), err)
+	diff = DiffKibanaRole(desired, actual)
+	assert.True(s.T(), diff.HasChanges())
+	assert.Equal(s.T(), []KibanaRoleChange{{Kind: KibanaRoleChangeModified, Path: "kibana[default,test].feature.dashboard", Desired: `["read"]`, Actual: `["all"]`}}, diff.Changes)
+
+	err = s.API.KibanaRoleManagement.Delete("test-diff")
+	assert.NoError(s.T(), err)
+}
+
+func TestDiffKibanaRole(t *testing.T) {
+
+	desired := &KibanaRole{
+		Name:        "test",
+		Description: "Read logs",
+		Metadata: map[string]interface{}{
+			"owner": "team-a",
+		},
+		Elasticsearch: &KibanaRoleElasticsearch{
+			Cluster: []string{"monitor", "manage"},
+			Indices: []KibanaRoleElasticsearchIndice{
+				{
+					Names:      []string{"logs-*", "app-*"},
+					Privileges: []string{"read", "view_index_metadata"},
+					FieldSecurity: &KibanaRoleFieldSecurity{
+						Grant: []string{"message", "@timestamp"},
+					},
+					Query: `{"term": {"public": true}}`,
+				},
+				{
+					Names:      []string{"metrics-*"},
+					Privileges: []string{"read"},
+				},
+			},
+		},
+		Kibana: []KibanaRoleKibana{
+			{
+				Base: []string{"read"},
+			},
+			{
+				Feature: map[string][]string{
+					"discover": {"all"},
+				},
+				Spaces: []string{"ops", "dev"},
+			},
+		},
+	}
+
+	// Same role returned by Kibana with other order and server fields
+	actual := &KibanaRole{
+		Name:        "test",
+		Description: "Read logs",
+		Metadata: map[string]interface{}{
+			"owner":     "team-a",
+			"_reserved": false,
+		},
+		TransientMedata: &KibanaRoleTransientMetadata{Enabled: true},
+		Elasticsearch: &KibanaRoleElasticsearch{
+			Cluster: []string{"manage", "monitor"},
+			Indices: []KibanaRoleElasticsearchIndice{
+				{
+					Names:      []string{"metrics-*"},
+					Privileges: []string{"read"},
+				},
+				{
+					Names:      []string{"app-*", "logs-*"},
+					Privileges: []string{"view_index_metadata", "read"},
+					FieldSecurity: &KibanaRoleFieldSecurity{
+						Grant: []string{"@timestamp", "message"},
+					},
+					Query: `{"term":{"public":true}}`,
+				},
+			},
+			RunAs: []string{},
+		},
+		Kibana: []KibanaRoleKibana{
+			{
+				Feature: map[string][]string{
+					"discover": {"all"},
+				},
+				Spaces:   []string{"dev", "ops"},
+				Reserved: []string{},
+			},
+			{
+				Base:    []string{"read"},
+				Feature: map[string][]string{},
+				Spaces:  []string{"*"},
+			},
+		},
+		TransformErrors:          []string{},
+		UnrecognizedApplications: []string{},
+	}
+	diff := DiffKibanaRole(desired, actual)
+	assert.False(t, diff.HasChanges(), diff.String())
+	assert.Equal(t, "Role test: no changes", diff.String())
+
+	// Role modified
+	actual.Description = ""
+	actual.Metadata["owner"] = "team-b"
+	actual.Elasticsearch.Indices[0].Privileges = []string{"read", "monitor"}
+	actual.Elasticsearch.Indices[1].Query = `{"match_all":{}}`
+	actual.Kibana[0].Feature["dashboard"] = []string{"read"}
+	actual.Kibana = actual.Kibana[:1]
+	diff = DiffKibanaRole(desired, actual)
+	assert.True(t, diff.HasChanges())
+	assert.Equal(t, []KibanaRoleChange{
+		{Kind: KibanaRoleChangeAdded, Path: "description", Desired: `"Read logs"`},
+		{Kind: KibanaRoleChangeModified, Path: "elasticsearch.indices[app-*,logs-*].query", Desired: `{"term":{"public":true}}`, Actual: `{"match_all":{}}`},
+		{Kind: KibanaRoleChangeModified, Path: "elasticsearch.indices[metrics-*].privileges", Desired: `["read"]`, Actual: `["monitor","read"]`},
+		{Kind: KibanaRoleChangeAdded, Path: "kibana[*].base", Desired: `["read"]`},
+		{Kind: KibanaRoleChangeRemoved, Path: "kibana[dev,ops].feature.dashboard", Actual: `["read"]`},
+		{Kind: KibanaRoleChangeModified, Path: "metadata.owner", Desired: `"team-a"`, Actual: `"team-b"`},
+	}, diff.Changes)
+	assert.Equal(t, `Role test: 6 changes
++ description: "Read logs"
+~ elasticsearch.indices[app-*,logs-*].query: {"match_all":{}} => {"term":{"public":true}}
+~ elasticsearch.indices[metrics-*].privileges: ["monitor","read"] => ["read"]
++ kibana[*].base: ["read"]
+- kibana[dev,ops].feature.dashboard: ["read"]
+~ metadata.owner: "team-b" => "team-a"`, diff.String())
+
+	// Same key on indices and remote cluster
+	desired = &KibanaRole{
+		Name: "test",
+		Elasticsearch: &KibanaRoleElasticsearch{
+			RemoteCluster: []KibanaRoleElasticsearchRemoteCluster{
+				{Clusters: []string{"remote"}, Privileges: []string{"monitor_enrich"}},
+			},
+		},
+	}
+	actual = &KibanaRole{
+		Name: "test",
+		Elasticsearch: &KibanaRoleElasticsearch{
+			Indices: []KibanaRoleElasticsearchIndice{
+				{Names: []string{"remote"}, Privileges: []string{"read"}},
+			},
+			RemoteCluster: []KibanaRoleElasticsearchRemoteCluster{
+				{Clusters: []string{"remote"}, Privileges: []string{"monitor_enrich"}},
+			},
+		},
+	}
+	diff = DiffKibanaRole(desired, actual)
+	assert.Equal(t, []KibanaRoleChange{
+		{Kind: KibanaRoleChangeRemoved, Path: "elasticsearch.indices[remote]", Actual: "{}"},
+		{Kind: KibanaRoleChangeRemoved, Path: "elasticsearch.indices[remote].privileges", Actual: `["read"]`},
+	}, diff.Changes)
+
+	// Role not exist
+	diff = DiffKibanaRole(desired, nil)
+	assert.True(t, diff.HasChanges())
+	assert.Equal(t, KibanaRoleChangeAdded, diff.Changes[0].Kind)
+}
@@ -0,0 +1,231 @@
+package kbapi
+
+import (
+	"sort"
+)
+
+// KibanaRolePrivilegeChecker permit to evaluate offline what a role can do in spaces, from the features catalog.
+// Base privileges are expanded on all features, and privileges granted on * apply to all spaces.
+type KibanaRolePrivilegeChecker struct {
+	role     *KibanaRole
+	features KibanaFeatures
+}
+
+// NewKibanaRolePrivilegeChecker create the privilege checker of the role
+func NewKibanaRolePrivilegeChecker(kibanaRole *KibanaRole, features KibanaFeatures) *KibanaRolePrivilegeChecker {
+	if kibanaRole == nil {
+		kibanaRole = &KibanaRole{}
+	}
+	return &KibanaRolePrivilegeChecker{
+		role:     kibanaRole,
+		features: features,
+	}
+}
+
+// FeaturePrivileges return the effective privileges of each feature in the space.
+// all include read, and all / read include the sub feature privileges they grant.
+// minimal_all and minimal_read stay separate privileges, as they exclude the sub feature privileges.
+// Use * as space to get the privileges granted on all spaces.
+func (c *KibanaRolePrivilegeChecker) FeaturePrivileges(space string) map[string][]string {
+	granted := make(map[string]map[string]bool)
+	grant := func(featureID string, privileges ...string) {
+		if granted[featureID] == nil {
+			granted[featureID] = make(map[string]bool)
+		}
+		for _, privilege := range privileges {
+			granted[featureID][privilege] = true
+		}
+	}
+
+	for _, kibana := range c.role.Kibana {
+		if !kibanaRoleMatchSpace(kibana.Spaces, space) {
+			continue
+		}
+
+		for _, base := range kibana.Base {
+			for i := range c.features {
+				feature := &c.features[i]
+				if feature.ExcludeFromBasePrivileges || feature.Privileges == nil {
+					continue
+				}
+				if base == "all" && feature.Privileges.All != nil {
+					grant(feature.ID, c.expandPrivilege(feature, "all")...)
+				}
+				if (base == "all" || base == "read") && feature.Privileges.Read != nil {
+					grant(feature.ID, c.expandPrivilege(feature, "read")...)
+				}
+			}
+		}
+
+		for featureID, privileges := range kibana.Feature {
+			feature := c.features.Get(featureID)
+			for _, privilege := range privileges {
+				if feature == nil {
+					grant(featureID, privilege)
+					continue
+				}
+				grant(featureID, c.expandPrivilege(feature, privilege)...)
+			}
+		}
+	}
+
+	result := make(map[string][]string, len(granted))
+	for featureID, privileges := range granted {
+		list := make([]string, 0, len(privileges))
+		for privilege := range privileges {
+			list = append(list, privilege)
+		}
+		sort.Strings(list)
+		result[featureID] = list
+	}
+
+	return result
+}
+
+// HasFeaturePrivilege return true if the role grant the privilege on the feature in the space
+// For example HasFeaturePrivilege("ops", "dashboard", "read")
+func (c *KibanaRolePrivilegeChecker) HasFeaturePrivilege(space string, featureID string, privilege string) bool {
+	return stringInSlice(privilege, c.FeaturePrivileges(space)[featureID])
+}
+
+// CanReadSavedObject return true if the role can read the saved object type in the space
+func (c *KibanaRolePrivilegeChecker) CanReadSavedObject(space string, objectType string) bool {
+	return c.hasSavedObjectAccess(space, objectType, false)
+}
+
+// CanWriteSavedObject return true if the role can create, update and delete the saved object type in the space
+func (c *KibanaRolePrivilegeChecker) CanWriteSavedObject(space string, objectType string) bool {
+	return c.hasSavedObjectAccess(space, objectType, true)
+}
+
+// SpacesWithFeaturePrivilege return the spaces where the role grant the privilege on the feature
+// The list contain * when the privilege is granted on all spaces
+func (c *KibanaRolePrivilegeChecker) SpacesWithFeaturePrivilege(featureID string, privilege string) []string {
+	spaces := make([]string, 0)
+	for _, space := range c.spaces() {
+		if c.HasFeaturePrivilege(space, featureID, privilege) {
+			spaces = append(spaces, space)
+		}
+	}
+	return spaces
+}
+
+// SpacesWithSavedObjectRead return the spaces where the role can read the saved object type
+// The list contain * when it can read it on all spaces
+func (c *KibanaRolePrivilegeChecker) SpacesWithSavedObjectRead(objectType string) []string {
+	spaces := make([]string, 0)
+	for _, space := range c.spaces() {
+		if c.CanReadSavedObject(space, objectType) {
+			spaces = append(spaces, space)
+		}
+	}
+	return spaces
+}
+
+// SpacesWithSavedObjectWrite return the spaces where the role can write the saved object type
+// The list contain * when it can write it on all spaces
+func (c *KibanaRolePrivilegeChecker) SpacesWithSavedObjectWrite(objectType string) []string {
+	spaces := make([]string, 0)
+	for _, space := range c.spaces() {
+		if c.CanWriteSavedObject(space, objectType) {
+			spaces = append(spaces, space)
+		}
+	}
+	return spaces
+}
+
+// hasSavedObjectAccess check the saved object types granted by the effective privileges
+func (c *KibanaRolePrivilegeChecker) hasSavedObjectAccess(space string, objectType string, write bool) bool {
+	for featureID, privileges := range c.FeaturePrivileges(space) {
+		feature := c.features.Get(featureID)
+		if feature == nil {
+			continue
+		}
+		for _, privilege := range privileges {
+			definition := featurePrivilegeDefinition(feature, privilege)
+			if definition == nil || definition.SavedObject == nil {
+				continue
+			}
+			if stringInSlice(objectType, definition.SavedObject.All) {
+				return true
+			}
+			if !write && stringInSlice(objectType, definition.SavedObject.Read) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandPrivilege return the privileges implied by the privilege granted on feature
+func (c *KibanaRolePrivilegeChecker) expandPrivilege(feature *KibanaFeature, privilege string) []string {
+	privileges := []string{privilege}
+	switch privilege {
+	case "all":
+		privileges = append(privileges, "read", "minimal_all", "minimal_read")
+	case "read":
+		privileges = append(privileges, "minimal_read")
+	case "minimal_all":
+		privileges = append(privileges, "minimal_read")
+	}
+
+	// Sub feature privileges included on all or read, but not on minimal privileges
+	if privilege == "all" || privilege == "read" {
+		for _, subFeature := range feature.SubFeatures {
+			for _, group := range subFeature.PrivilegeGroups {
+				for _, subPrivilege := range group.Privileges {
+					if (subPrivilege.IncludeIn == "all" && privilege == "all") || subPrivilege.IncludeIn == "read" {
+						privileges = append(privileges, subPrivilege.ID)
+					}
+				}
+			}
+		}
+	}
+
+	return privileges
+}
+
+// spaces return * and the spaces used by the role, sorted
+func (c *KibanaRolePrivilegeChecker) spaces() []string {
+	spaces := []string{"*"}
+	for _, kibana := range c.role.Kibana {
+		for _, space := range kibana.Spaces {
+			if !stringInSlice(space, spaces) {
+				spaces = append(spaces, space)
+			}
+		}
+	}
+	sort.Strings(spaces)
+	return spaces
+}
+
+// featurePrivilegeDefinition return the definition of feature privilege or sub feature privilege
+func featurePrivilegeDefinition(feature *KibanaFeature, privilege string) *KibanaFeaturePrivilege {
+	if feature.Privileges != nil {
+		switch privilege {
+		case "all", "minimal_all":
+			return feature.Privileges.All
+		case "read", "minimal_read":
+			return feature.Privileges.Read
+		}
+	}
+	for _, subFeature := range feature.SubFeatures {
+		for _, group := range subFeature.PrivilegeGroups {
+			for i := range group.Privileges {
+				if group.Privileges[i].ID == privilege {
+					return &group.Privileges[i].KibanaFeaturePrivilege
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// kibanaRoleMatchSpace return true if privileges granted on spaces apply to space
+// Kibana grant privileges on all spaces when no space is provided
+func kibanaRoleMatchSpace(spaces []string, space string) bool {
+	if len(spaces) == 0 {
+		return true
+	}
+	return stringInSlice("*", spaces) || stringInSlice(space, spaces)
+}
@@ -0,0 +1,141 @@
+package kbapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *KBAPITestSuite) TestKibanaRolePrivilegeChecker() {
+
+	kibanaFeatures, err := s.API.KibanaFeatures.List()
+	assert.NoError(s.T(), err)
+
+	kibanaRole := &KibanaRole{
+		Kibana: []KibanaRoleKibana{
+			{
+				Base:   []string{"read"},
+				Spaces: []string{"ops"},
+			},
+		},
+	}
+	checker := NewKibanaRolePrivilegeChecker(kibanaRole, kibanaFeatures)
+	assert.True(s.T(), checker.HasFeaturePrivilege("ops", "dashboard", "read"))
+	assert.False(s.T(), checker.HasFeaturePrivilege("ops", "dashboard", "all"))
+	assert.True(s.T(), checker.CanReadSavedObject("ops", "dashboard"))
+	assert.False(s.T(), checker.CanWriteSavedObject("ops", "dashboard"))
+}
+
+func TestKibanaRolePrivilegeChecker(t *testing.T) {
+
+	kibanaFeatures := KibanaFeatures{
+		{
+			ID: "dashboard",
+			Privileges: &KibanaFeaturePrivileges{
+				All: &KibanaFeaturePrivilege{
+					SavedObject: &KibanaFeatureSavedObjectPrivilege{All: []string{"dashboard"}, Read: []string{"index-pattern", "visualization"}},
+				},
+				Read: &KibanaFeaturePrivilege{
+					SavedObject: &KibanaFeatureSavedObjectPrivilege{Read: []string{"dashboard", "index-pattern", "visualization"}},
+				},
+			},
+			SubFeatures: []KibanaSubFeature{
+				{
+					Name: "Short URLs",
+					PrivilegeGroups: []KibanaSubFeaturePrivilegeGroup{
+						{
+							GroupType: "independent",
+							Privileges: []KibanaSubFeaturePrivilege{
+								{
+									ID:        "url_create",
+									IncludeIn: "all",
+									KibanaFeaturePrivilege: KibanaFeaturePrivilege{
+										SavedObject: &KibanaFeatureSavedObjectPrivilege{All: []string{"url"}},
+									},
+								},
+								{
+									ID:        "store_search_session",
+									IncludeIn: "read",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			ID: "visualize",
+			Privileges: &KibanaFeaturePrivileges{
+				All: &KibanaFeaturePrivilege{
+					SavedObject: &KibanaFeatureSavedObjectPrivilege{All: []string{"visualization", "lens"}},
+				},
+				Read: &KibanaFeaturePrivilege{
+					SavedObject: &KibanaFeatureSavedObjectPrivilege{Read: []string{"visualization", "lens"}},
+				},
+			},
+		},
+		{
+			ID:                        "fleet",
+			ExcludeFromBasePrivileges: true,
+			Privileges: &KibanaFeaturePrivileges{
+				All: &KibanaFeaturePrivilege{},
+			},
+		},
+	}
+
+	kibanaRole := &KibanaRole{
+		Kibana: []KibanaRoleKibana{
+			{
+				Base:   []string{"read"},
+				Spaces: []string{"*"},
+			},
+			{
+				Base:   []string{"all"},
+				Spaces: []string{"ops"},
+			},
+			{
+				Feature: map[string][]string{
+					"visualize": {"all"},
+					"dashboard": {"minimal_all"},
+				},
+				Spaces: []string{"dev", "qa"},
+			},
+		},
+	}
+	checker := NewKibanaRolePrivilegeChecker(kibanaRole, kibanaFeatures)
+
+	// Base read on all spaces
+	assert.Equal(t, map[string][]string{
+		"dashboard": {"minimal_read", "read", "store_search_session"},
+		"visualize": {"minimal_read", "read"},
+	}, checker.FeaturePrivileges("*"))
+	assert.True(t, checker.HasFeaturePrivilege("other", "dashboard", "read"))
+	assert.False(t, checker.HasFeaturePrivilege("other", "dashboard", "all"))
+	assert.True(t, checker.CanReadSavedObject("other", "visualization"))
+	assert.False(t, checker.CanWriteSavedObject("other", "visualization"))
+
+	// Base all on ops, except feature excluded from base privileges
+	assert.True(t, checker.HasFeaturePrivilege("ops", "dashboard", "all"))
+	assert.True(t, checker.HasFeaturePrivilege("ops", "dashboard", "url_create"))
+	assert.False(t, checker.HasFeaturePrivilege("ops", "fleet", "all"))
+	assert.True(t, checker.CanWriteSavedObject("ops", "url"))
+
+	// Minimal privileges not include all, read and sub features
+	assert.Equal(t, []string{"minimal_all", "minimal_read", "read", "store_search_session"}, checker.FeaturePrivileges("dev")["dashboard"])
+	assert.True(t, checker.HasFeaturePrivilege("dev", "dashboard", "minimal_all"))
+	assert.False(t, checker.HasFeaturePrivilege("dev", "dashboard", "all"))
+	assert.False(t, checker.HasFeaturePrivilege("dev", "dashboard", "url_create"))
+	assert.False(t, checker.HasFeaturePrivilege("qa", "dashboard", "all"))
+	assert.True(t, checker.CanWriteSavedObject("dev", "dashboard"))
+	assert.False(t, checker.CanWriteSavedObject("dev", "url"))
+
+	// Spaces where visualizations can be written
+	assert.Equal(t, []string{"dev", "ops", "qa"}, checker.SpacesWithSavedObjectWrite("visualization"))
+	assert.Equal(t, []string{"*", "dev", "ops", "qa"}, checker.SpacesWithSavedObjectRead("visualization"))
+	assert.Equal(t, []string{"ops"}, checker.SpacesWithFeaturePrivilege("dashboard", "url_create"))
+
+	// Role without privileges
+	checker = NewKibanaRolePrivilegeChecker(nil, kibanaFeatures)
+	assert.Empty(t, checker.FeaturePrivileges("*"))
+	assert.Empty(t, checker.SpacesWithSavedObjectRead("dashboard"))
+}
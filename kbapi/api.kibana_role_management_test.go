@@ -1,6 +1,13 @@
 package kbapi
 
 import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,11 +36,73 @@ func (s *KBAPITestSuite) TestKibanaRoleManagement() {
 			},
 		},
 	}
-	kibanaRole, err := s.API.KibanaRoleManagement.CreateOrUpdate(kibanaRole)
+	kibanaRoleResult, err := s.API.KibanaRoleManagement.CreateOrUpdate(kibanaRole)
 	assert.NoError(s.T(), err)
-	assert.NotNil(s.T(), kibanaRole)
+	assert.NotNil(s.T(), kibanaRoleResult)
+	assert.Equal(s.T(), "test", kibanaRoleResult.Name)
 	assert.Equal(s.T(), "test", kibanaRole.Name)
 
+	// Create role that already exist
+	_, err = s.API.KibanaRoleManagement.Create(kibanaRole)
+	assert.Error(s.T(), err)
+	assert.True(s.T(), IsConflictError(err))
+
+	// Update role
+	kibanaRole.Kibana[0].Base = []string{"all"}
+	kibanaRoleResult, err = s.API.KibanaRoleManagement.Update(kibanaRole)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"all"}, kibanaRoleResult.Kibana[0].Base)
+
+	// Update role that not exist
+	_, err = s.API.KibanaRoleManagement.Update(&KibanaRole{Name: "test-not-exist"})
+	assert.Error(s.T(), err)
+
+	// Create role
+	kibanaRoleResult, err = s.API.KibanaRoleManagement.Create(&KibanaRole{
+		Name: "test-create",
+		Kibana: []KibanaRoleKibana{
+			{
+				Base: []string{"read"},
+			},
+		},
+	})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "test-create", kibanaRoleResult.Name)
+	err = s.API.KibanaRoleManagement.Delete("test-create")
+	assert.NoError(s.T(), err)
+
+	// Create role with field and document level security
+	query, err := NewKibanaRoleQuery(map[string]interface{}{"match": map[string]interface{}{"category": "public"}})
+	assert.NoError(s.T(), err)
+	kibanaRoleResult, err = s.API.KibanaRoleManagement.CreateOrUpdate(&KibanaRole{
+		Name: "test-security",
+		Elasticsearch: &KibanaRoleElasticsearch{
+			Indices: []KibanaRoleElasticsearchIndice{
+				{
+					Names:      []string{"test-*"},
+					Privileges: []string{"read"},
+					FieldSecurity: &KibanaRoleFieldSecurity{
+						Grant:  []string{"*"},
+						Except: []string{"secret"},
+					},
+					Query: query,
+				},
+			},
+		},
+	})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"secret"}, kibanaRoleResult.Elasticsearch.Indices[0].FieldSecurity.Except)
+	queryMap, err := kibanaRoleResult.Elasticsearch.Indices[0].Query.Map()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "public", queryMap["match"].(map[string]interface{})["category"])
+	err = s.API.KibanaRoleManagement.Delete("test-security")
+	assert.NoError(s.T(), err)
+
+	// Get reserved role
+	kibanaRoleResult, err = s.API.KibanaRoleManagement.Get("kibana_admin")
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), kibanaRoleResult.IsReserved())
+
 	// Get role
 	kibanaRole, err = s.API.KibanaRoleManagement.Get("test")
 	assert.NoError(s.T(), err)
@@ -53,3 +122,137 @@ func (s *KBAPITestSuite) TestKibanaRoleManagement() {
 	assert.Nil(s.T(), kibanaRole)
 
 }
+
+func TestKibanaRoleManagementWrite(t *testing.T) {
+
+	var payload []byte
+	var createOnly string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			payload, _ = io.ReadAll(r.Body)
+			createOnly = r.URL.Query().Get("createOnly")
+			if createOnly == "true" && r.URL.Path == "/api/security/role/exist" {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			if r.URL.Path == "/api/security/role/not-exist" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"exist","kibana":[{"base":["read"],"spaces":["*"]}]}`))
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+
+	kibanaRole := &KibanaRole{
+		Name: "exist",
+		Kibana: []KibanaRoleKibana{
+			{
+				Base:   []string{"read"},
+				Spaces: []string{"*"},
+			},
+		},
+	}
+
+	// Create or update not modify the caller object
+	_, err := api.KibanaRoleManagement.CreateOrUpdate(kibanaRole)
+	assert.NoError(t, err)
+	assert.Equal(t, "exist", kibanaRole.Name)
+	assert.JSONEq(t, `{"kibana":[{"base":["read"],"spaces":["*"]}]}`, string(payload))
+	assert.Empty(t, createOnly)
+
+	// Create failed if role already exist
+	_, err = api.KibanaRoleManagement.Create(kibanaRole)
+	assert.Error(t, err)
+	assert.True(t, IsConflictError(err))
+	assert.Equal(t, "true", createOnly)
+	assert.Equal(t, "exist", kibanaRole.Name)
+
+	// Update failed if role not exist
+	payload = nil
+	_, err = api.KibanaRoleManagement.Update(&KibanaRole{Name: "not-exist"})
+	assert.Error(t, err)
+	assert.False(t, IsConflictError(err))
+	assert.Nil(t, payload)
+
+	// Update role
+	_, err = api.KibanaRoleManagement.Update(kibanaRole)
+	assert.NoError(t, err)
+	assert.Empty(t, createOnly)
+
+	// Role name is mandatory
+	_, err = api.KibanaRoleManagement.CreateOrUpdate(&KibanaRole{})
+	assert.Error(t, err)
+}
+
+func TestKibanaRoleModel(t *testing.T) {
+
+	// Read role returned by Kibana
+	kibanaRole := &KibanaRole{}
+	err := json.Unmarshal([]byte(`{
+		"name": "test",
+		"metadata": {"_reserved": true, "_deprecated": true, "_deprecated_reason": "use other"},
+		"elasticsearch": {
+			"cluster": ["monitor"],
+			"indices": [{"names": ["logs-*"], "privileges": ["read"], "field_security": {"grant": ["*"], "except": ["secret"]}, "query": "{\"match_all\":{}}", "allow_restricted_indices": true}],
+			"remote_indices": [{"clusters": ["remote-*"], "names": ["logs-*"], "privileges": ["read"], "query": {"term": {"public": true}}}],
+			"remote_cluster": [{"clusters": ["remote-*"], "privileges": ["monitor_enrich"]}]
+		},
+		"kibana": [{"base": [], "feature": {"discover": ["read"]}, "spaces": ["*"], "_reserved": ["monitoring"]}],
+		"_transform_error": [],
+		"_unrecognized_applications": ["other"]
+	}`), kibanaRole)
+	assert.NoError(t, err)
+	assert.True(t, kibanaRole.IsReserved())
+	assert.True(t, kibanaRole.IsDeprecated())
+	assert.Equal(t, "use other", kibanaRole.DeprecatedReason())
+	assert.Equal(t, &KibanaRoleFieldSecurity{Grant: []string{"*"}, Except: []string{"secret"}}, kibanaRole.Elasticsearch.Indices[0].FieldSecurity)
+	assert.Equal(t, KibanaRoleQuery(`{"match_all":{}}`), kibanaRole.Elasticsearch.Indices[0].Query)
+	assert.True(t, kibanaRole.Elasticsearch.Indices[0].AllowRestrictedIndices)
+	assert.Equal(t, KibanaRoleQuery(`{"term":{"public":true}}`), kibanaRole.Elasticsearch.RemoteIndices[0].Query)
+	assert.Equal(t, []string{"monitor_enrich"}, kibanaRole.Elasticsearch.RemoteCluster[0].Privileges)
+	assert.Equal(t, []string{"monitoring"}, kibanaRole.Kibana[0].Reserved)
+	assert.Equal(t, []string{"other"}, kibanaRole.UnrecognizedApplications)
+
+	// Query is always sent as JSON string
+	b, err := json.Marshal(kibanaRole.Elasticsearch.RemoteIndices[0])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"clusters":["remote-*"],"names":["logs-*"],"privileges":["read"],"query":"{\"term\":{\"public\":true}}"}`, string(b))
+
+	// Query from string or object
+	query, err := NewKibanaRoleQuery(`{"match_all":{}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, KibanaRoleQuery(`{"match_all":{}}`), query)
+	query, err = NewKibanaRoleQuery(map[string]interface{}{"match_all": map[string]interface{}{}})
+	assert.NoError(t, err)
+	assert.Equal(t, KibanaRoleQuery(`{"match_all":{}}`), query)
+	queryMap, err := query.Map()
+	assert.NoError(t, err)
+	assert.Contains(t, queryMap, "match_all")
+	queryMap, err = KibanaRoleQuery("").Map()
+	assert.NoError(t, err)
+	assert.Nil(t, queryMap)
+
+	// Read only fields are not sent to Kibana
+	var payload []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			payload, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"test"}`))
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+	_, err = api.KibanaRoleManagement.CreateOrUpdate(kibanaRole)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(payload), "_transform_error")
+	assert.NotContains(t, string(payload), "_unrecognized_applications")
+	assert.NotContains(t, string(payload), `"_reserved":["monitoring"]`)
+	assert.Equal(t, []string{"monitoring"}, kibanaRole.Kibana[0].Reserved)
+}
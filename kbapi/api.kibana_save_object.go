@@ -27,6 +27,50 @@ type OptionalFindParameters struct {
 	HasReference          string
 }
 
+// OptionalCreateParameters contain optional parameters to create object
+type OptionalCreateParameters struct {
+	// References is the list of objects referenced by the new object
+	References []KibanaSavedObjectReference
+	// InitialNamespaces is the list of spaces where the object is created. Use "*" to share it with all spaces
+	InitialNamespaces []string
+	// MigrationVersion is the version of each plugin migration applied on attributes
+	MigrationVersion map[string]string
+	// CoreMigrationVersion is the Kibana version the attributes comply with
+	CoreMigrationVersion string
+}
+
+// OptionalUpdateParameters contain optional parameters to update object
+type OptionalUpdateParameters struct {
+	// Version is the version returned by Get or Find. When set, Kibana reject the update
+	// with 409 Conflict if the object was modified since
+	Version string
+	// References replace the list of objects referenced by the object
+	References []KibanaSavedObjectReference
+	// Upsert is the attributes used to create the object if it not exist yet
+	Upsert map[string]interface{}
+}
+
+// KibanaSavedObjectReference is the reference to another saved object
+type KibanaSavedObjectReference struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// KibanaSavedObjectBulkUpdateRequest is one object to update with bulk update
+type KibanaSavedObjectBulkUpdateRequest struct {
+	Type       string                       `json:"type"`
+	ID         string                       `json:"id"`
+	Attributes map[string]interface{}       `json:"attributes"`
+	Version    string                       `json:"version,omitempty"`
+	References []KibanaSavedObjectReference `json:"references,omitempty"`
+	Namespace  string                       `json:"namespace,omitempty"`
+}
+
+// KibanaSavedObjectMutateFunc is the function called by GetAndUpdate to modify the current object.
+// It receive the object returned by Get and must modify its attributes or references in place.
+type KibanaSavedObjectMutateFunc func(data map[string]interface{}) error
+
 // KibanaSavedObjectGet permit to get saved object from Kibana
 type KibanaSavedObjectGet func(objectType string, id string, kibanaSpace string) (map[string]interface{}, error)
 
@@ -34,10 +78,16 @@ type KibanaSavedObjectGet func(objectType string, id string, kibanaSpace string)
 type KibanaSavedObjectFind func(objectType string, kibanaSpace string, optionalParameters *OptionalFindParameters) (map[string]interface{}, error)
 
 // KibanaSavedObjectCreate permit to create saved object in Kibana
-type KibanaSavedObjectCreate func(data map[string]interface{}, objectType string, id string, overwrite bool, kibanaSpace string) (map[string]interface{}, error)
+type KibanaSavedObjectCreate func(data map[string]interface{}, objectType string, id string, overwrite bool, kibanaSpace string, optionalParameters *OptionalCreateParameters) (map[string]interface{}, error)
 
 // KibanaSavedObjectUpdate permit to update saved object in Kibana
-type KibanaSavedObjectUpdate func(data map[string]interface{}, objectType string, id string, kibanaSpace string) (map[string]interface{}, error)
+type KibanaSavedObjectUpdate func(data map[string]interface{}, objectType string, id string, kibanaSpace string, optionalParameters *OptionalUpdateParameters) (map[string]interface{}, error)
+
+// KibanaSavedObjectBulkUpdate permit to update many saved objects in Kibana
+type KibanaSavedObjectBulkUpdate func(objects []KibanaSavedObjectBulkUpdateRequest, kibanaSpace string) (map[string]interface{}, error)
+
+// KibanaSavedObjectGetAndUpdate permit to get saved object, modify it and update it in Kibana. It retry on conflict
+type KibanaSavedObjectGetAndUpdate func(objectType string, id string, kibanaSpace string, mutate KibanaSavedObjectMutateFunc, maxRetries int) (map[string]interface{}, error)
 
 // KibanaSavedObjectDelete permit to delete saved object in Kibana
 type KibanaSavedObjectDelete func(objectType string, id string, kibanaSpace string) error
@@ -54,6 +104,18 @@ func (o *OptionalFindParameters) String() string {
 	return string(json)
 }
 
+// String permit to return OptionalCreateParameters object as JSON string
+func (o *OptionalCreateParameters) String() string {
+	json, _ := json.Marshal(o)
+	return string(json)
+}
+
+// String permit to return OptionalUpdateParameters object as JSON string
+func (o *OptionalUpdateParameters) String() string {
+	json, _ := json.Marshal(o)
+	return string(json)
+}
+
 // newKibanaSavedObjectGetFunc permit to get saved obejct by it id and type
 func newKibanaSavedObjectGetFunc(c *resty.Client) KibanaSavedObjectGet {
 	return func(objectType string, id string, kibanaSpace string) (map[string]interface{}, error) {
@@ -182,7 +244,7 @@ func newKibanaSavedObjectFindFunc(c *resty.Client) KibanaSavedObjectFind {
 
 // newKibanaSavedObjectCreateFunc permit to create new object on Kibana
 func newKibanaSavedObjectCreateFunc(c *resty.Client) KibanaSavedObjectCreate {
-	return func(data map[string]interface{}, objectType string, id string, overwrite bool, kibanaSpace string) (map[string]interface{}, error) {
+	return func(data map[string]interface{}, objectType string, id string, overwrite bool, kibanaSpace string, optionalParameters *OptionalCreateParameters) (map[string]interface{}, error) {
 
 		if data == nil {
 			return nil, NewAPIError(600, "You must provide one or more dashboard to import")
@@ -196,6 +258,30 @@ func newKibanaSavedObjectCreateFunc(c *resty.Client) KibanaSavedObjectCreate {
 		log.Debug("Overwrite: ", overwrite)
 		log.Debug("KibanaSpace: ", kibanaSpace)
 
+		// Copy data to not modify the caller object
+		payload := make(map[string]interface{}, len(data)+4)
+		for key, value := range data {
+			payload[key] = value
+		}
+		if optionalParameters != nil {
+			log.Debug("References: ", optionalParameters.References)
+			log.Debug("InitialNamespaces: ", optionalParameters.InitialNamespaces)
+			log.Debug("MigrationVersion: ", optionalParameters.MigrationVersion)
+			log.Debug("CoreMigrationVersion: ", optionalParameters.CoreMigrationVersion)
+			if optionalParameters.References != nil {
+				payload["references"] = optionalParameters.References
+			}
+			if len(optionalParameters.InitialNamespaces) > 0 {
+				payload["initialNamespaces"] = optionalParameters.InitialNamespaces
+			}
+			if len(optionalParameters.MigrationVersion) > 0 {
+				payload["migrationVersion"] = optionalParameters.MigrationVersion
+			}
+			if optionalParameters.CoreMigrationVersion != "" {
+				payload["coreMigrationVersion"] = optionalParameters.CoreMigrationVersion
+			}
+		}
+
 		var path string
 		if kibanaSpace == "" || kibanaSpace == "default" {
 			path = fmt.Sprintf("%s/%s/%s", basePathKibanaSavedObject, objectType, id)
@@ -204,7 +290,7 @@ func newKibanaSavedObjectCreateFunc(c *resty.Client) KibanaSavedObjectCreate {
 		}
 		log.Debugf("URL to create object: %s", path)
 
-		jsonData, err := json.Marshal(data)
+		jsonData, err := json.Marshal(payload)
 		if err != nil {
 			return nil, err
 		}
@@ -229,7 +315,7 @@ func newKibanaSavedObjectCreateFunc(c *resty.Client) KibanaSavedObjectCreate {
 
 // newKibanaSavedObjectUpdateFunc permit to update object on Kibana
 func newKibanaSavedObjectUpdateFunc(c *resty.Client) KibanaSavedObjectUpdate {
-	return func(data map[string]interface{}, objectType string, id string, kibanaSpace string) (map[string]interface{}, error) {
+	return func(data map[string]interface{}, objectType string, id string, kibanaSpace string, optionalParameters *OptionalUpdateParameters) (map[string]interface{}, error) {
 
 		if data == nil {
 			return nil, NewAPIError(600, "You must provide one or more dashboard to import")
@@ -245,6 +331,26 @@ func newKibanaSavedObjectUpdateFunc(c *resty.Client) KibanaSavedObjectUpdate {
 		log.Debug("ID: ", id)
 		log.Debug("kibanaSpace: ", kibanaSpace)
 
+		// Copy data to not modify the caller object
+		payload := make(map[string]interface{}, len(data)+3)
+		for key, value := range data {
+			payload[key] = value
+		}
+		if optionalParameters != nil {
+			log.Debug("Version: ", optionalParameters.Version)
+			log.Debug("References: ", optionalParameters.References)
+			log.Debug("Upsert: ", optionalParameters.Upsert)
+			if optionalParameters.Version != "" {
+				payload["version"] = optionalParameters.Version
+			}
+			if optionalParameters.References != nil {
+				payload["references"] = optionalParameters.References
+			}
+			if optionalParameters.Upsert != nil {
+				payload["upsert"] = optionalParameters.Upsert
+			}
+		}
+
 		var path string
 		if kibanaSpace == "" || kibanaSpace == "default" {
 			path = fmt.Sprintf("%s/%s/%s", basePathKibanaSavedObject, objectType, id)
@@ -253,7 +359,7 @@ func newKibanaSavedObjectUpdateFunc(c *resty.Client) KibanaSavedObjectUpdate {
 		}
 		log.Debugf("URL to update object: %s", path)
 
-		jsonData, err := json.Marshal(data)
+		jsonData, err := json.Marshal(payload)
 		if err != nil {
 			return nil, err
 		}
@@ -263,6 +369,12 @@ func newKibanaSavedObjectUpdateFunc(c *resty.Client) KibanaSavedObjectUpdate {
 		}
 		log.Debug("Response: ", resp)
 		if resp.StatusCode() >= 300 {
+			if resp.StatusCode() == 409 {
+				if version, ok := payload["version"].(string); ok && version != "" {
+					return nil, NewConflictError([]ConflictObject{{Type: objectType, ID: id}}, "Saved object %s/%s has been modified since version %s", objectType, id, version)
+				}
+				return nil, NewConflictError([]ConflictObject{{Type: objectType, ID: id}}, "Saved object %s/%s is in conflict", objectType, id)
+			}
 			return nil, NewAPIError(resp.StatusCode(), resp.Status())
 		}
 		var dataResponse map[string]interface{}
@@ -276,6 +388,123 @@ func newKibanaSavedObjectUpdateFunc(c *resty.Client) KibanaSavedObjectUpdate {
 	}
 }
 
+// newKibanaSavedObjectBulkUpdateFunc permit to update many objects on Kibana
+// When some objects are rejected because of version conflict, it return the response and a ConflictError
+func newKibanaSavedObjectBulkUpdateFunc(c *resty.Client) KibanaSavedObjectBulkUpdate {
+	return func(objects []KibanaSavedObjectBulkUpdateRequest, kibanaSpace string) (map[string]interface{}, error) {
+
+		if len(objects) == 0 {
+			return nil, NewAPIError(600, "You must provide one or more objects to update")
+		}
+		for _, object := range objects {
+			if object.Type == "" || object.ID == "" {
+				return nil, NewAPIError(600, "You must provide the type and the ID of each object")
+			}
+		}
+		log.Debug("Objects: ", objects)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		var path string
+		if kibanaSpace == "" || kibanaSpace == "default" {
+			path = fmt.Sprintf("%s/_bulk_update", basePathKibanaSavedObject)
+		} else {
+			path = fmt.Sprintf("/s/%s%s/_bulk_update", kibanaSpace, basePathKibanaSavedObject)
+		}
+		log.Debugf("URL to bulk update objects: %s", path)
+
+		jsonData, err := json.Marshal(objects)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.R().SetBody(jsonData).Post(path)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+		var dataResponse map[string]interface{}
+		err = json.Unmarshal(resp.Body(), &dataResponse)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Data response: ", dataResponse)
+
+		// Search objects rejected because of version conflict
+		var conflicts []ConflictObject
+		savedObjects, _ := dataResponse["saved_objects"].([]interface{})
+		for _, savedObject := range savedObjects {
+			object, ok := savedObject.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			objectError, ok := object["error"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if statusCode, _ := objectError["statusCode"].(float64); statusCode == 409 {
+				objectType, _ := object["type"].(string)
+				objectID, _ := object["id"].(string)
+				conflicts = append(conflicts, ConflictObject{Type: objectType, ID: objectID})
+			}
+		}
+		if len(conflicts) > 0 {
+			return dataResponse, NewConflictError(conflicts, "%d saved objects have been modified since the provided version", len(conflicts))
+		}
+
+		return dataResponse, nil
+	}
+}
+
+// newKibanaSavedObjectGetAndUpdateFunc permit to get object, apply mutate function and update it with the version read.
+// It retry up to maxRetries time when the object is modified by someone else between get and update
+func newKibanaSavedObjectGetAndUpdateFunc(c *resty.Client) KibanaSavedObjectGetAndUpdate {
+	return func(objectType string, id string, kibanaSpace string, mutate KibanaSavedObjectMutateFunc, maxRetries int) (map[string]interface{}, error) {
+
+		if mutate == nil {
+			return nil, NewAPIError(600, "You must provide the mutate function")
+		}
+		log.Debug("MaxRetries: ", maxRetries)
+
+		get := newKibanaSavedObjectGetFunc(c)
+		update := newKibanaSavedObjectUpdateFunc(c)
+
+		for try := 0; ; try++ {
+			data, err := get(objectType, id, kibanaSpace)
+			if err != nil {
+				return nil, err
+			}
+			if data == nil {
+				return nil, NewAPIError(404, "Saved object %s/%s not found", objectType, id)
+			}
+			version, _ := data["version"].(string)
+
+			if err = mutate(data); err != nil {
+				return nil, err
+			}
+
+			payload := map[string]interface{}{
+				"attributes": data["attributes"],
+			}
+			if references, ok := data["references"]; ok {
+				payload["references"] = references
+			}
+
+			dataResponse, err := update(payload, objectType, id, kibanaSpace, &OptionalUpdateParameters{Version: version})
+			if err != nil {
+				if IsConflictError(err) && try < maxRetries {
+					log.Debugf("Conflict when update saved object %s/%s, retry %d", objectType, id, try+1)
+					continue
+				}
+				return nil, err
+			}
+
+			return dataResponse, nil
+		}
+	}
+}
+
 // newKibanaSavedObjectDeleteFunc permit to delete object on Kibana
 func newKibanaSavedObjectDeleteFunc(c *resty.Client) KibanaSavedObjectDelete {
 	return func(objectType string, id string, kibanaSpace string) error {
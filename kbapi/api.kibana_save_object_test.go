@@ -2,7 +2,11 @@ package kbapi
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,7 +19,7 @@ func (s *KBAPITestSuite) TestKibanaSaveObject() {
 	if err != nil {
 		panic(err)
 	}
-	resp, err := s.API.KibanaSavedObject.Create(data, "index-pattern", "test", true, "default")
+	resp, err := s.API.KibanaSavedObject.Create(data, "index-pattern", "test", true, "default", nil)
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), resp)
 	assert.Equal(s.T(), "test", resp["id"])
@@ -28,12 +32,74 @@ func (s *KBAPITestSuite) TestKibanaSaveObject() {
 	if err != nil {
 		panic(err)
 	}
-	resp, err = s.API.KibanaSavedObject.Create(data, "index-pattern", "test2", true, "testacc")
+	resp, err = s.API.KibanaSavedObject.Create(data, "index-pattern", "test2", true, "testacc", nil)
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), resp)
 	assert.Equal(s.T(), "test2", resp["id"])
 	assert.Equal(s.T(), "test-pattern-*", resp["attributes"].(map[string]interface{})["title"])
 
+	// Create new shared index pattern in many spaces
+	dataJSON = `{"attributes": {"title": "test-shared-*"}}`
+	data = make(map[string]interface{})
+	err = json.Unmarshal([]byte(dataJSON), &data)
+	if err != nil {
+		panic(err)
+	}
+	resp, err = s.API.KibanaSavedObject.Create(data, "index-pattern", "test-shared", true, "default", &OptionalCreateParameters{
+		InitialNamespaces: []string{"default", "testacc"},
+	})
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), resp)
+	assert.ElementsMatch(s.T(), []interface{}{"default", "testacc"}, resp["namespaces"])
+
+	// Create search with reference to shared index pattern
+	dataJSON = `{"attributes": {"title": "test-search", "kibanaSavedObjectMeta": {"searchSourceJSON": "{\"indexRefName\":\"kibanaSavedObjectMeta.searchSourceJSON.index\"}"}}}`
+	data = make(map[string]interface{})
+	err = json.Unmarshal([]byte(dataJSON), &data)
+	if err != nil {
+		panic(err)
+	}
+	resp, err = s.API.KibanaSavedObject.Create(data, "search", "test-search", true, "default", &OptionalCreateParameters{
+		References: []KibanaSavedObjectReference{
+			{
+				Type: "index-pattern",
+				ID:   "test-shared",
+				Name: "kibanaSavedObjectMeta.searchSourceJSON.index",
+			},
+		},
+	})
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), resp)
+	assert.Len(s.T(), resp["references"], 1)
+
+	// Upsert index pattern that not exist
+	dataJSON = `{"attributes": {"title": "test-upsert-*"}}`
+	data = make(map[string]interface{})
+	err = json.Unmarshal([]byte(dataJSON), &data)
+	if err != nil {
+		panic(err)
+	}
+	resp, err = s.API.KibanaSavedObject.Update(data, "index-pattern", "test-upsert", "default", &OptionalUpdateParameters{
+		Upsert: map[string]interface{}{"title": "test-upsert-*"},
+	})
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), resp)
+	assert.Equal(s.T(), "test-upsert", resp["id"])
+
+	// Clean shared objects
+	err = s.API.KibanaSavedObject.Delete("search", "test-search", "default")
+	assert.NoError(s.T(), err)
+	err = s.API.KibanaSavedObject.Delete("index-pattern", "test-upsert", "default")
+	assert.NoError(s.T(), err)
+	// Object in many spaces must be removed from other spaces before delete it
+	_, err = s.API.KibanaSpaces.UpdateObjectsSpaces(&KibanaSpaceUpdateObjectsSpacesParameter{
+		Objects:        []KibanaSpaceObjectParameter{{Type: "index-pattern", ID: "test-shared"}},
+		SpacesToRemove: []string{"testacc"},
+	}, "")
+	assert.NoError(s.T(), err)
+	err = s.API.KibanaSavedObject.Delete("index-pattern", "test-shared", "default")
+	assert.NoError(s.T(), err)
+
 	// Get index pattern
 	resp, err = s.API.KibanaSavedObject.Get("index-pattern", "test", "default")
 	assert.NoError(s.T(), err)
@@ -76,7 +142,7 @@ func (s *KBAPITestSuite) TestKibanaSaveObject() {
 	if err != nil {
 		panic(err)
 	}
-	resp, err = s.API.KibanaSavedObject.Update(data, "index-pattern", "test", "default")
+	resp, err = s.API.KibanaSavedObject.Update(data, "index-pattern", "test", "default", nil)
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), resp)
 	assert.Equal(s.T(), "test", resp["id"])
@@ -88,12 +154,60 @@ func (s *KBAPITestSuite) TestKibanaSaveObject() {
 	if err != nil {
 		panic(err)
 	}
-	resp, err = s.API.KibanaSavedObject.Update(data, "index-pattern", "test2", "testacc")
+	resp, err = s.API.KibanaSavedObject.Update(data, "index-pattern", "test2", "testacc", nil)
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), resp)
 	assert.Equal(s.T(), "test2", resp["id"])
 	assert.Equal(s.T(), "test-pattern2-*", resp["attributes"].(map[string]interface{})["title"])
 
+	// Update index pattern with the current version
+	resp, err = s.API.KibanaSavedObject.Get("index-pattern", "test", "default")
+	assert.NoError(s.T(), err)
+	version := resp["version"].(string)
+	dataJSON = `{"attributes": {"title": "test-pattern3-*"}}`
+	err = json.Unmarshal([]byte(dataJSON), &data)
+	if err != nil {
+		panic(err)
+	}
+	resp, err = s.API.KibanaSavedObject.Update(data, "index-pattern", "test", "default", &OptionalUpdateParameters{Version: version})
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), resp)
+	assert.Equal(s.T(), "test-pattern3-*", resp["attributes"].(map[string]interface{})["title"])
+
+	// Update index pattern with outdated version
+	_, err = s.API.KibanaSavedObject.Update(data, "index-pattern", "test", "default", &OptionalUpdateParameters{Version: version})
+	assert.Error(s.T(), err)
+	assert.True(s.T(), IsConflictError(err))
+
+	// Bulk update index pattern with outdated version
+	bulkRequest := []KibanaSavedObjectBulkUpdateRequest{
+		{
+			Type:       "index-pattern",
+			ID:         "test",
+			Attributes: map[string]interface{}{"title": "test-pattern4-*"},
+			Version:    version,
+		},
+	}
+	resp, err = s.API.KibanaSavedObject.BulkUpdate(bulkRequest, "default")
+	assert.Error(s.T(), err)
+	assert.True(s.T(), IsConflictError(err))
+	assert.NotNil(s.T(), resp)
+
+	// Bulk update index pattern without version
+	bulkRequest[0].Version = ""
+	resp, err = s.API.KibanaSavedObject.BulkUpdate(bulkRequest, "default")
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), resp)
+
+	// Get and update index pattern
+	resp, err = s.API.KibanaSavedObject.GetAndUpdate("index-pattern", "test", "default", func(data map[string]interface{}) error {
+		data["attributes"].(map[string]interface{})["title"] = "test-pattern2-*"
+		return nil
+	}, 3)
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), resp)
+	assert.Equal(s.T(), "test-pattern2-*", resp["attributes"].(map[string]interface{})["title"])
+
 	// Export index pattern
 	request := []map[string]string{
 		{
@@ -150,3 +264,23 @@ func (s *KBAPITestSuite) TestKibanaSaveObject() {
 	assert.NoError(s.T(), err)
 	assert.Nil(s.T(), resp)
 }
+
+func TestKibanaSavedObjectUpdateConflict(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+	data := map[string]interface{}{"attributes": map[string]interface{}{"title": "test"}}
+
+	// Conflict with version
+	_, err := api.KibanaSavedObject.Update(data, "index-pattern", "test", "default", &OptionalUpdateParameters{Version: "WzEsMV0="})
+	assert.True(t, IsConflictError(err))
+	assert.EqualError(t, err, "Saved object index-pattern/test has been modified since version WzEsMV0=")
+
+	// Conflict without version
+	_, err = api.KibanaSavedObject.Update(data, "index-pattern", "test", "default", nil)
+	assert.True(t, IsConflictError(err))
+	assert.EqualError(t, err, "Saved object index-pattern/test is in conflict")
+}
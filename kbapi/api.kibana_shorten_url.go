@@ -2,6 +2,9 @@ package kbapi
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	log "github.com/sirupsen/logrus"
@@ -19,15 +22,36 @@ type ShortenURL struct {
 	HumanReadableSlug bool           `json:"humanReadableSlug,omitempty"`
 }
 
-// ShortenURLResponse is the shorten URL object response
+// ShortenURLResponse is the shorten URL record returned by Kibana
+// Dates are epoch in milliseconds
 type ShortenURLResponse struct {
-	ID      string      `json:"id"`
-	Locator *ShortenURL `json:"locator"`
+	ID          string             `json:"id"`
+	Slug        string             `json:"slug,omitempty"`
+	AccessCount int64              `json:"accessCount"`
+	AccessDate  int64              `json:"accessDate"`
+	CreateDate  int64              `json:"createDate"`
+	Locator     *ShortenURLLocator `json:"locator"`
+}
+
+// ShortenURLLocator is the locator saved on shorten URL record
+type ShortenURLLocator struct {
+	ID      string         `json:"id"`
+	Version string         `json:"version,omitempty"`
+	State   map[string]any `json:"state,omitempty"`
 }
 
 // KibanaShortenURLCreate permit to create new shorten URL
 type KibanaShortenURLCreate func(shortenURL *ShortenURL) (*ShortenURLResponse, error)
 
+// KibanaShortenURLGet permit to get shorten URL by its ID
+type KibanaShortenURLGet func(id string) (*ShortenURLResponse, error)
+
+// KibanaShortenURLResolve permit to get shorten URL by its slug
+type KibanaShortenURLResolve func(slug string) (*ShortenURLResponse, error)
+
+// KibanaShortenURLDelete permit to delete shorten URL by its ID
+type KibanaShortenURLDelete func(id string) error
+
 // String permit to return ShortenURL object as JSON string
 func (o *ShortenURL) String() string {
 	json, _ := json.Marshal(o)
@@ -40,6 +64,16 @@ func (o *ShortenURLResponse) String() string {
 	return string(json)
 }
 
+// AccessTime return the last access date
+func (o *ShortenURLResponse) AccessTime() time.Time {
+	return time.UnixMilli(o.AccessDate)
+}
+
+// CreateTime return the creation date
+func (o *ShortenURLResponse) CreateTime() time.Time {
+	return time.UnixMilli(o.CreateDate)
+}
+
 // newKibanaShortenURLCreateFunc permit to create new shorten URL
 func newKibanaShortenURLCreateFunc(c *resty.Client) KibanaShortenURLCreate {
 	return func(shortenURL *ShortenURL) (*ShortenURLResponse, error) {
@@ -76,3 +110,80 @@ func newKibanaShortenURLCreateFunc(c *resty.Client) KibanaShortenURLCreate {
 		return shortenURLResponse, nil
 	}
 }
+
+// newKibanaShortenURLGetFunc permit to get shorten URL by its ID
+// It return nil if shorten URL not exist
+func newKibanaShortenURLGetFunc(c *resty.Client) KibanaShortenURLGet {
+	return func(id string) (*ShortenURLResponse, error) {
+
+		if id == "" {
+			return nil, NewAPIError(600, "You must provide shorten URL ID")
+		}
+		log.Debug("ID: ", id)
+
+		path := fmt.Sprintf("%s/%s", basePathKibanaShortenURL, url.PathEscape(id))
+		return getKibanaShortenURL(c, path)
+	}
+}
+
+// newKibanaShortenURLResolveFunc permit to get shorten URL by its slug
+// It return nil if shorten URL not exist
+func newKibanaShortenURLResolveFunc(c *resty.Client) KibanaShortenURLResolve {
+	return func(slug string) (*ShortenURLResponse, error) {
+
+		if slug == "" {
+			return nil, NewAPIError(600, "You must provide shorten URL slug")
+		}
+		log.Debug("Slug: ", slug)
+
+		path := fmt.Sprintf("%s/_slug/%s", basePathKibanaShortenURL, url.PathEscape(slug))
+		return getKibanaShortenURL(c, path)
+	}
+}
+
+// newKibanaShortenURLDeleteFunc permit to delete shorten URL by its ID
+func newKibanaShortenURLDeleteFunc(c *resty.Client) KibanaShortenURLDelete {
+	return func(id string) error {
+
+		if id == "" {
+			return NewAPIError(600, "You must provide shorten URL ID")
+		}
+		log.Debug("ID: ", id)
+
+		path := fmt.Sprintf("%s/%s", basePathKibanaShortenURL, url.PathEscape(id))
+		resp, err := c.R().Delete(path)
+		if err != nil {
+			return err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return NewAPIError(resp.StatusCode(), resp.Status())
+		}
+
+		return nil
+	}
+}
+
+// getKibanaShortenURL read the shorten URL record on path
+func getKibanaShortenURL(c *resty.Client, path string) (*ShortenURLResponse, error) {
+	resp, err := c.R().Get(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Response: ", resp)
+	if resp.StatusCode() >= 300 {
+		if resp.StatusCode() == 404 {
+			return nil, nil
+		}
+		return nil, NewAPIError(resp.StatusCode(), resp.Status())
+	}
+
+	shortenURLResponse := &ShortenURLResponse{}
+	err = json.Unmarshal(resp.Body(), shortenURLResponse)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("ShortenURLResponse: ", shortenURLResponse)
+
+	return shortenURLResponse, nil
+}
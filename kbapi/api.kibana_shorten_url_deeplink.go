@@ -0,0 +1,134 @@
+package kbapi
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/disaster37/go-kibana-rest/v8/rison"
+)
+
+// DeepLink return the dashboard URL with its state on Rison _g and _a parameters.
+// baseURL is the Kibana address, like http://localhost:5601. Keep it empty to get the relative URL
+// to use with NewLegacyShortenURL. space can be empty for default space
+func (o *DashboardLocatorParams) DeepLink(baseURL string, space string) (string, error) {
+	if err := o.Validate(); err != nil {
+		return "", err
+	}
+
+	globalState, appState := locatorURLState(o.TimeRange, o.RefreshInterval, o.Filters, o.Query)
+	if o.ViewMode != "" {
+		appState["viewMode"] = o.ViewMode
+	}
+
+	path := "/app/dashboards#/create"
+	if o.DashboardID != "" {
+		path = fmt.Sprintf("/app/dashboards#/view/%s", url.PathEscape(o.DashboardID))
+	}
+	return buildDeepLink(baseURL, space, path, globalState, appState)
+}
+
+// DeepLink return the Discover URL with its state on Rison _g and _a parameters.
+// baseURL is the Kibana address, like http://localhost:5601. Keep it empty to get the relative URL
+// to use with NewLegacyShortenURL. space can be empty for default space
+func (o *DiscoverLocatorParams) DeepLink(baseURL string, space string) (string, error) {
+	if err := o.Validate(); err != nil {
+		return "", err
+	}
+
+	globalState, appState := locatorURLState(o.TimeRange, o.RefreshInterval, o.Filters, o.Query)
+	if o.DataViewID != "" {
+		appState["index"] = o.DataViewID
+	}
+	if len(o.Columns) > 0 {
+		appState["columns"] = o.Columns
+	}
+	if o.Interval != "" {
+		appState["interval"] = o.Interval
+	}
+	if len(o.Sort) > 0 {
+		appState["sort"] = o.Sort
+	}
+	if o.ViewMode != "" {
+		appState["viewMode"] = o.ViewMode
+	}
+
+	path := "/app/discover#/"
+	if o.SavedSearchID != "" {
+		path = fmt.Sprintf("/app/discover#/view/%s", url.PathEscape(o.SavedSearchID))
+	}
+	return buildDeepLink(baseURL, space, path, globalState, appState)
+}
+
+// NewLegacyShortenURL return the shorten URL to create for the relative URL, like the deep link
+func NewLegacyShortenURL(relativeURL string) *ShortenURL {
+	return &ShortenURL{
+		LocatorId: ShortenURLLocatorLegacy,
+		Params: map[string]any{
+			"url": relativeURL,
+		},
+	}
+}
+
+// locatorURLState split the params between global state (_g) and app state (_a)
+// Filters are on app state, except the pinned ones
+func locatorURLState(timeRange *LocatorTimeRange, refreshInterval *LocatorRefreshInterval, filters []LocatorFilter, query *LocatorQuery) (globalState map[string]any, appState map[string]any) {
+	globalState = map[string]any{}
+	appState = map[string]any{}
+
+	if timeRange != nil {
+		globalState["time"] = timeRange
+	}
+	if refreshInterval != nil {
+		globalState["refreshInterval"] = refreshInterval
+	}
+	globalFilters := make([]LocatorFilter, 0)
+	appFilters := make([]LocatorFilter, 0)
+	for _, filter := range filters {
+		if filter.State != nil && filter.State.Store == LocatorFilterStoreGlobal {
+			globalFilters = append(globalFilters, filter)
+		} else {
+			appFilters = append(appFilters, filter)
+		}
+	}
+	if len(globalFilters) > 0 {
+		globalState["filters"] = globalFilters
+	}
+	if len(appFilters) > 0 {
+		appState["filters"] = appFilters
+	}
+	if query != nil {
+		appState["query"] = query
+	}
+
+	return globalState, appState
+}
+
+// buildDeepLink return the URL of app path with Rison state
+func buildDeepLink(baseURL string, space string, path string, globalState map[string]any, appState map[string]any) (string, error) {
+	parameters := make([]string, 0, 2)
+	for _, state := range []struct {
+		name  string
+		value map[string]any
+	}{{name: "_g", value: globalState}, {name: "_a", value: appState}} {
+		if len(state.value) == 0 {
+			continue
+		}
+		s, err := rison.Encode(state.value)
+		if err != nil {
+			return "", err
+		}
+		parameters = append(parameters, fmt.Sprintf("%s=%s", state.name, rison.QueryEscape(s)))
+	}
+
+	link := strings.TrimSuffix(baseURL, "/")
+	if space != "" && space != "default" {
+		link = fmt.Sprintf("%s/s/%s", link, space)
+	}
+	link += path
+	if len(parameters) > 0 {
+		link = fmt.Sprintf("%s?%s", link, strings.Join(parameters, "&"))
+	}
+
+	return link, nil
+}
@@ -0,0 +1,75 @@
+package kbapi
+
+import (
+	"testing"
+
+	"github.com/disaster37/go-kibana-rest/v8/rison"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboardDeepLink(t *testing.T) {
+
+	pinnedFilter := NewLocatorPhraseFilter("", "env", "prod")
+	pinnedFilter.State.Store = LocatorFilterStoreGlobal
+	params := &DashboardLocatorParams{
+		DashboardID: "edf84fe0-e1a0-11e7-b6d5-4dc382ef7f5b",
+		TimeRange: &LocatorTimeRange{
+			From: "now-15m",
+			To:   "now",
+		},
+		Filters: []LocatorFilter{pinnedFilter, NewLocatorPhraseFilter("logs", "host.name", "web 1")},
+		Query: &LocatorQuery{
+			Query:    "status:500",
+			Language: LocatorQueryLanguageKQL,
+		},
+		ViewMode: LocatorViewModeView,
+	}
+
+	link, err := params.DeepLink("http://localhost:5601/", "ops")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:5601/s/ops/app/dashboards#/view/edf84fe0-e1a0-11e7-b6d5-4dc382ef7f5b?"+
+		"_g=(filters:!(('$state':(store:globalState),meta:(disabled:!f,key:env,negate:!f,params:(query:prod),type:phrase),query:(match_phrase:(env:prod)))),time:(from:now-15m,to:now))&"+
+		"_a=(filters:!(('$state':(store:appState),meta:(disabled:!f,index:logs,key:host.name,negate:!f,params:(query:'web+1'),type:phrase),query:(match_phrase:(host.name:'web+1')))),query:(language:kuery,query:'status:500'),viewMode:view)", link)
+
+	// Relative link to shorten
+	link, err = (&DashboardLocatorParams{DashboardID: "123"}).DeepLink("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "/app/dashboards#/view/123", link)
+	shortenURL := NewLegacyShortenURL(link)
+	assert.Equal(t, ShortenURLLocatorLegacy, shortenURL.LocatorId)
+	assert.Equal(t, map[string]any{"url": "/app/dashboards#/view/123"}, shortenURL.Params)
+
+	_, err = (&DashboardLocatorParams{ViewMode: "print"}).DeepLink("", "")
+	assert.Error(t, err)
+}
+
+func TestDiscoverDeepLink(t *testing.T) {
+
+	params := &DiscoverLocatorParams{
+		DataViewID: "logs",
+		TimeRange: &LocatorTimeRange{
+			From: "now-1h",
+			To:   "now",
+		},
+		RefreshInterval: &LocatorRefreshInterval{Pause: false, Value: 10000},
+		Columns:         []string{"message"},
+		Sort:            [][]string{{"@timestamp", "desc"}},
+	}
+
+	link, err := params.DeepLink("", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "/app/discover#/?_g=(refreshInterval:(pause:!f,value:10000),time:(from:now-1h,to:now))&_a=(columns:!(message),index:logs,sort:!(!('@timestamp',desc)))", link)
+
+	// The state can be decoded
+	appState, err := rison.Decode("(columns:!(message),index:logs,sort:!(!('@timestamp',desc)))")
+	assert.NoError(t, err)
+	assert.Equal(t, "logs", appState.(map[string]any)["index"])
+
+	link, err = (&DiscoverLocatorParams{SavedSearchID: "abc"}).DeepLink("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "/app/discover#/view/abc", link)
+
+	link, err = (&DiscoverLocatorParams{ViewMode: LocatorDiscoverViewModeAggregated}).DeepLink("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "/app/discover#/?_a=(viewMode:aggregated)", link)
+}
@@ -0,0 +1,212 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Locators of shorten URL
+const (
+	ShortenURLLocatorDashboard = "DASHBOARD_APP_LOCATOR"
+	ShortenURLLocatorDiscover  = "DISCOVER_APP_LOCATOR"
+	ShortenURLLocatorLegacy    = "LEGACY_SHORT_URL_LOCATOR"
+)
+
+// View mode of dashboard
+const (
+	LocatorViewModeView = "view"
+	LocatorViewModeEdit = "edit"
+)
+
+// View mode of Discover
+const (
+	LocatorDiscoverViewModeDocuments  = "documents"
+	LocatorDiscoverViewModeAggregated = "aggregated"
+	LocatorDiscoverViewModePatterns   = "patterns"
+)
+
+// Query language
+const (
+	LocatorQueryLanguageKQL    = "kuery"
+	LocatorQueryLanguageLucene = "lucene"
+)
+
+// Filter store, appState is the app filters and globalState is the pinned filters
+const (
+	LocatorFilterStoreApp    = "appState"
+	LocatorFilterStoreGlobal = "globalState"
+)
+
+// LocatorTimeRange is the time range, like now-15m to now
+type LocatorTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Mode string `json:"mode,omitempty"`
+}
+
+// LocatorRefreshInterval is the auto refresh, value is in milliseconds
+type LocatorRefreshInterval struct {
+	Pause bool  `json:"pause"`
+	Value int64 `json:"value"`
+}
+
+// LocatorQuery is the query of search bar
+type LocatorQuery struct {
+	Query    any    `json:"query"`
+	Language string `json:"language"`
+}
+
+// LocatorFilter is the filter of filter bar
+type LocatorFilter struct {
+	Meta  map[string]any      `json:"meta"`
+	Query map[string]any      `json:"query,omitempty"`
+	State *LocatorFilterState `json:"$state,omitempty"`
+}
+
+// LocatorFilterState is the store of filter
+type LocatorFilterState struct {
+	Store string `json:"store"`
+}
+
+// DashboardLocatorParams is the params of DASHBOARD_APP_LOCATOR
+type DashboardLocatorParams struct {
+	DashboardID          string                  `json:"dashboardId,omitempty"`
+	TimeRange            *LocatorTimeRange       `json:"timeRange,omitempty"`
+	RefreshInterval      *LocatorRefreshInterval `json:"refreshInterval,omitempty"`
+	Filters              []LocatorFilter         `json:"filters,omitempty"`
+	Query                *LocatorQuery           `json:"query,omitempty"`
+	UseHash              bool                    `json:"useHash"`
+	PreserveSavedFilters *bool                   `json:"preserveSavedFilters,omitempty"`
+	ViewMode             string                  `json:"viewMode,omitempty"`
+	SearchSessionID      string                  `json:"searchSessionId,omitempty"`
+	SavedQuery           string                  `json:"savedQuery,omitempty"`
+}
+
+// DiscoverLocatorParams is the params of DISCOVER_APP_LOCATOR
+// Sort is the list of field and direction, like [["@timestamp", "desc"]]
+type DiscoverLocatorParams struct {
+	SavedSearchID   string                  `json:"savedSearchId,omitempty"`
+	DataViewID      string                  `json:"dataViewId,omitempty"`
+	TimeRange       *LocatorTimeRange       `json:"timeRange,omitempty"`
+	RefreshInterval *LocatorRefreshInterval `json:"refreshInterval,omitempty"`
+	Filters         []LocatorFilter         `json:"filters,omitempty"`
+	Query           *LocatorQuery           `json:"query,omitempty"`
+	UseHash         bool                    `json:"useHash"`
+	Columns         []string                `json:"columns,omitempty"`
+	Interval        string                  `json:"interval,omitempty"`
+	Sort            [][]string              `json:"sort,omitempty"`
+	SavedQuery      string                  `json:"savedQuery,omitempty"`
+	ViewMode        string                  `json:"viewMode,omitempty"`
+}
+
+// NewLocatorPhraseFilter return filter that match the field value, like field: "value"
+// index is the data view ID, it can be empty
+func NewLocatorPhraseFilter(index string, field string, value any) LocatorFilter {
+	meta := map[string]any{
+		"key":      field,
+		"type":     "phrase",
+		"negate":   false,
+		"disabled": false,
+		"params": map[string]any{
+			"query": value,
+		},
+	}
+	if index != "" {
+		meta["index"] = index
+	}
+
+	return LocatorFilter{
+		Meta: meta,
+		Query: map[string]any{
+			"match_phrase": map[string]any{
+				field: value,
+			},
+		},
+		State: &LocatorFilterState{
+			Store: LocatorFilterStoreApp,
+		},
+	}
+}
+
+// Validate permit to check the dashboard params
+func (o *DashboardLocatorParams) Validate() error {
+	if o.ViewMode != "" && o.ViewMode != LocatorViewModeView && o.ViewMode != LocatorViewModeEdit {
+		return NewAPIError(600, "View mode must be %s or %s", LocatorViewModeView, LocatorViewModeEdit)
+	}
+	return validateLocatorParams(o.TimeRange, o.Query)
+}
+
+// ShortenURL return the shorten URL to create for the dashboard
+func (o *DashboardLocatorParams) ShortenURL() (*ShortenURL, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return NewShortenURL(ShortenURLLocatorDashboard, o)
+}
+
+// Validate permit to check the Discover params
+// Without saved search and data view, Discover use the default data view
+func (o *DiscoverLocatorParams) Validate() error {
+	switch o.ViewMode {
+	case "", LocatorDiscoverViewModeDocuments, LocatorDiscoverViewModeAggregated, LocatorDiscoverViewModePatterns:
+	default:
+		return NewAPIError(600, "View mode must be %s, %s or %s", LocatorDiscoverViewModeDocuments, LocatorDiscoverViewModeAggregated, LocatorDiscoverViewModePatterns)
+	}
+	for _, sort := range o.Sort {
+		if len(sort) != 2 || (sort[1] != "asc" && sort[1] != "desc") {
+			return NewAPIError(600, "Sort %v must be field and direction asc or desc", sort)
+		}
+	}
+	return validateLocatorParams(o.TimeRange, o.Query)
+}
+
+// ShortenURL return the shorten URL to create for Discover
+func (o *DiscoverLocatorParams) ShortenURL() (*ShortenURL, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return NewShortenURL(ShortenURLLocatorDiscover, o)
+}
+
+// NewShortenURL return the shorten URL of locator with params serialized like Kibana expect them
+func NewShortenURL(locatorID string, params any) (*ShortenURL, error) {
+	if locatorID == "" {
+		return nil, NewAPIError(600, "You must provide the locator ID")
+	}
+
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	paramsMap := map[string]any{}
+	if err = json.Unmarshal(b, &paramsMap); err != nil {
+		return nil, err
+	}
+
+	return &ShortenURL{
+		LocatorId: locatorID,
+		Params:    paramsMap,
+	}, nil
+}
+
+// GotoURL return the full URL that redirect to the shorten URL target.
+// baseURL is the Kibana address, like http://localhost:5601, and space can be empty for default space
+func (o *ShortenURLResponse) GotoURL(baseURL string, space string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if space == "" || space == "default" {
+		return fmt.Sprintf("%s/goto/%s", baseURL, o.ID)
+	}
+	return fmt.Sprintf("%s/s/%s/goto/%s", baseURL, space, o.ID)
+}
+
+// validateLocatorParams check the params shared by locators
+func validateLocatorParams(timeRange *LocatorTimeRange, query *LocatorQuery) error {
+	if timeRange != nil && (timeRange.From == "" || timeRange.To == "") {
+		return NewAPIError(600, "Time range must have from and to")
+	}
+	if query != nil && query.Language != LocatorQueryLanguageKQL && query.Language != LocatorQueryLanguageLucene {
+		return NewAPIError(600, "Query language must be %s or %s", LocatorQueryLanguageKQL, LocatorQueryLanguageLucene)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package kbapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboardLocatorParams(t *testing.T) {
+
+	preserveSavedFilters := false
+	params := &DashboardLocatorParams{
+		DashboardID: "edf84fe0-e1a0-11e7-b6d5-4dc382ef7f5b",
+		TimeRange: &LocatorTimeRange{
+			From: "now-15m",
+			To:   "now",
+		},
+		RefreshInterval: &LocatorRefreshInterval{
+			Pause: false,
+			Value: 60000,
+		},
+		Filters: []LocatorFilter{NewLocatorPhraseFilter("logs", "host.name", "web-1")},
+		Query: &LocatorQuery{
+			Query:    "status:500",
+			Language: LocatorQueryLanguageKQL,
+		},
+		PreserveSavedFilters: &preserveSavedFilters,
+		ViewMode:             LocatorViewModeView,
+	}
+	shortenURL, err := params.ShortenURL()
+	assert.NoError(t, err)
+	assert.Equal(t, ShortenURLLocatorDashboard, shortenURL.LocatorId)
+	b, err := json.Marshal(shortenURL.Params)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"dashboardId": "edf84fe0-e1a0-11e7-b6d5-4dc382ef7f5b",
+		"timeRange": {"from": "now-15m", "to": "now"},
+		"refreshInterval": {"pause": false, "value": 60000},
+		"filters": [{
+			"meta": {"index": "logs", "key": "host.name", "type": "phrase", "negate": false, "disabled": false, "params": {"query": "web-1"}},
+			"query": {"match_phrase": {"host.name": "web-1"}},
+			"$state": {"store": "appState"}
+		}],
+		"query": {"query": "status:500", "language": "kuery"},
+		"useHash": false,
+		"preserveSavedFilters": false,
+		"viewMode": "view"
+	}`, string(b))
+
+	// Bad params
+	_, err = (&DashboardLocatorParams{TimeRange: &LocatorTimeRange{From: "now-15m"}}).ShortenURL()
+	assert.Error(t, err)
+	_, err = (&DashboardLocatorParams{Query: &LocatorQuery{Query: "foo", Language: "sql"}}).ShortenURL()
+	assert.Error(t, err)
+	_, err = (&DashboardLocatorParams{ViewMode: "print"}).ShortenURL()
+	assert.Error(t, err)
+}
+
+func TestDiscoverLocatorParams(t *testing.T) {
+
+	params := &DiscoverLocatorParams{
+		DataViewID: "logs",
+		Columns:    []string{"message"},
+		Sort:       [][]string{{"@timestamp", "desc"}},
+	}
+	shortenURL, err := params.ShortenURL()
+	assert.NoError(t, err)
+	assert.Equal(t, ShortenURLLocatorDiscover, shortenURL.LocatorId)
+	b, err := json.Marshal(shortenURL.Params)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"dataViewId":"logs","columns":["message"],"sort":[["@timestamp","desc"]],"useHash":false}`, string(b))
+
+	// Discover view mode
+	shortenURL, err = (&DiscoverLocatorParams{ViewMode: LocatorDiscoverViewModeDocuments}).ShortenURL()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"viewMode": "documents", "useHash": false}, shortenURL.Params)
+
+	// Bad params
+	_, err = (&DiscoverLocatorParams{DataViewID: "logs", Sort: [][]string{{"@timestamp"}}}).ShortenURL()
+	assert.Error(t, err)
+	_, err = (&DiscoverLocatorParams{ViewMode: LocatorViewModeEdit}).ShortenURL()
+	assert.Error(t, err)
+}
+
+func TestShortenURLGotoURL(t *testing.T) {
+	shortenURLResponse := &ShortenURLResponse{ID: "abc"}
+	assert.Equal(t, "http://localhost:5601/goto/abc", shortenURLResponse.GotoURL("http://localhost:5601/", ""))
+	assert.Equal(t, "http://localhost:5601/goto/abc", shortenURLResponse.GotoURL("http://localhost:5601", "default"))
+	assert.Equal(t, "http://localhost:5601/s/ops/goto/abc", shortenURLResponse.GotoURL("http://localhost:5601", "ops"))
+}
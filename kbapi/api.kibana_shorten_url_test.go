@@ -1,6 +1,11 @@
 package kbapi
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,9 +17,81 @@ func (s *KBAPITestSuite) TestKibanaShortenURL() {
 		Params: map[string]any{
 			"url": "/app/kibana#/dashboard?_g=()&_a=(description:'',filters:!(),fullScreenMode:!f,options:(hidePanelTitles:!f,useMargins:!t),panels:!((embeddableConfig:(),gridData:(h:15,i:'1',w:24,x:0,y:0),id:'8f4d0c00-4c86-11e8-b3d7-01146121b73d',panelIndex:'1',type:visualization,version:'7.0.0-alpha1')),query:(language:lucene,query:''),timeRestore:!f,title:'New%20Dashboard',viewMode:edit)",
 		},
+		Slug: "acceptance-test",
 	}
 	shortenURLResponse, err := s.API.KibanaShortenURL.Create(shortenURL)
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), shortenURLResponse)
 	assert.NotEmpty(s.T(), shortenURLResponse.ID)
+	assert.Equal(s.T(), "LEGACY_SHORT_URL_LOCATOR", shortenURLResponse.Locator.ID)
+
+	// Get shorten URL
+	shortenURLGet, err := s.API.KibanaShortenURL.Get(shortenURLResponse.ID)
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), shortenURLGet)
+	assert.Equal(s.T(), "acceptance-test", shortenURLGet.Slug)
+
+	// Resolve shorten URL
+	shortenURLGet, err = s.API.KibanaShortenURL.Resolve("acceptance-test")
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), shortenURLGet)
+	assert.Equal(s.T(), shortenURLResponse.ID, shortenURLGet.ID)
+
+	// Delete shorten URL
+	err = s.API.KibanaShortenURL.Delete(shortenURLResponse.ID)
+	assert.NoError(s.T(), err)
+	shortenURLGet, err = s.API.KibanaShortenURL.Get(shortenURLResponse.ID)
+	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), shortenURLGet)
+}
+
+func TestKibanaShortenURLRecord(t *testing.T) {
+
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && (r.URL.Path == "/api/short_url/abc" || r.URL.Path == "/api/short_url/_slug/my-link"):
+			_, _ = w.Write([]byte(`{"id":"abc","slug":"my-link","accessCount":3,"accessDate":1671000000000,"createDate":1670000000000,"locator":{"id":"DASHBOARD_APP_LOCATOR","version":"8.5.0","state":{"dashboardId":"123"}}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/short_url/abc":
+			deleted = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+
+	shortenURL, err := api.KibanaShortenURL.Get("abc")
+	assert.NoError(t, err)
+	assert.Equal(t, &ShortenURLResponse{
+		ID:          "abc",
+		Slug:        "my-link",
+		AccessCount: 3,
+		AccessDate:  1671000000000,
+		CreateDate:  1670000000000,
+		Locator: &ShortenURLLocator{
+			ID:      "DASHBOARD_APP_LOCATOR",
+			Version: "8.5.0",
+			State:   map[string]any{"dashboardId": "123"},
+		},
+	}, shortenURL)
+	assert.Equal(t, int64(1671000000), shortenURL.AccessTime().Unix())
+	assert.Equal(t, int64(1670000000), shortenURL.CreateTime().Unix())
+
+	shortenURL, err = api.KibanaShortenURL.Resolve("my-link")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", shortenURL.ID)
+
+	shortenURL, err = api.KibanaShortenURL.Resolve("not-exist")
+	assert.NoError(t, err)
+	assert.Nil(t, shortenURL)
+
+	err = api.KibanaShortenURL.Delete("abc")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	err = api.KibanaShortenURL.Delete("not-exist")
+	assert.Error(t, err)
+
+	_, err = api.KibanaShortenURL.Get("")
+	assert.Error(t, err)
 }
@@ -1,8 +1,12 @@
 package kbapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/go-resty/resty/v2"
@@ -13,17 +17,71 @@ const (
 	basePathKibanaSpace = "/api/spaces" // Base URL to access on Kibana space API
 )
 
+// Solution view available on space since Kibana 8.16
+const (
+	KibanaSpaceSolutionSecurity      = "security"
+	KibanaSpaceSolutionObservability = "oblt"
+	KibanaSpaceSolutionSearch        = "es"
+	KibanaSpaceSolutionClassic       = "classic"
+)
+
+// Purpose to filter the spaces when list them
+const (
+	KibanaSpacePurposeAny                        = "any"
+	KibanaSpacePurposeCopySavedObjectsIntoSpace  = "copySavedObjectsIntoSpace"
+	KibanaSpacePurposeShareSavedObjectsIntoSpace = "shareSavedObjectsIntoSpace"
+)
+
+const (
+	basePathKibanaSavedObjectTypes = "/api/kibana/management/saved_objects/_allowed_types" // URL to get the saved object types that can be exported
+)
+
+var (
+	kibanaSpaceIDRegexp    = regexp.MustCompile(`^[a-z0-9_\-]+$`)
+	kibanaSpaceColorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
 // KibanaSpace is the Space API object
+// Extra contain the attributes returned by Kibana that are not handled by this library.
+// They are sent back as is when update the space.
 type KibanaSpace struct {
-	ID               string   `json:"id"`
-	Name             string   `json:"name"`
-	Description      string   `json:"description,omitempty"`
-	DisabledFeatures []string `json:"disabledFeatures,omitempty"`
-	Reserved         bool     `json:"_reserved,omitempty"`
-	Initials         string   `json:"initials,omitempty"`
-	Color            string   `json:"color,omitempty"`
+	ID               string                     `json:"id"`
+	Name             string                     `json:"name"`
+	Description      string                     `json:"description,omitempty"`
+	DisabledFeatures []string                   `json:"disabledFeatures,omitempty"`
+	Reserved         bool                       `json:"_reserved,omitempty"`
+	Initials         string                     `json:"initials,omitempty"`
+	Color            string                     `json:"color,omitempty"`
+	ImageURL         string                     `json:"imageUrl,omitempty"`
+	Solution         string                     `json:"solution,omitempty"`
+	Extra            map[string]json.RawMessage `json:"-"`
+
+	// AuthorizedPurposes is only returned by List when IncludeAuthorizedPurposes is set. It's never sent to Kibana
+	AuthorizedPurposes *KibanaSpaceAuthorizedPurposes `json:"authorizedPurposes,omitempty"`
 }
 
+// KibanaSpaceAuthorizedPurposes is what the current user is authorized to do in the space
+type KibanaSpaceAuthorizedPurposes struct {
+	Any                        bool `json:"any"`
+	CopySavedObjectsIntoSpace  bool `json:"copySavedObjectsIntoSpace"`
+	FindSavedObjects           bool `json:"findSavedObjects"`
+	ShareSavedObjectsIntoSpace bool `json:"shareSavedObjectsIntoSpace"`
+}
+
+// OptionalListSpacesParameters contain optional parameters to list spaces
+type OptionalListSpacesParameters struct {
+	// Purpose return only the spaces where the current user can do this action
+	Purpose string
+	// IncludeAuthorizedPurposes return the authorized purposes of each space. It can't be used with Purpose
+	IncludeAuthorizedPurposes bool
+}
+
+// kibanaSpaceJSON is used to marshal / unmarshal KibanaSpace without recursion
+type kibanaSpaceJSON KibanaSpace
+
+// kibanaSpaceFields is the list of attributes handled by KibanaSpace
+var kibanaSpaceFields = []string{"id", "name", "description", "disabledFeatures", "_reserved", "initials", "color", "imageUrl", "solution", "authorizedPurposes"}
+
 // KibanaSpaces is the list of KibanaSpace object
 type KibanaSpaces []KibanaSpace
 
@@ -42,11 +100,160 @@ type KibanaSpaceObjectParameter struct {
 	ID   string `json:"id"`
 }
 
+// OptionalSafeDeleteParameters contain optional parameters to delete space safely
+type OptionalSafeDeleteParameters struct {
+	// Confirm must be true to delete the space. Without it, only the backup and the roles report are done
+	Confirm bool
+	// ObjectTypes is the saved object types to export. Default to all types that Kibana can export.
+	// The delete is refused if the space has objects of other exportable types
+	ObjectTypes []string
+}
+
+// KibanaSpaceSafeDeleteResult is the report of the safe delete
+type KibanaSpaceSafeDeleteResult struct {
+	// ExportedBytes is the size of the ndjson backup written
+	ExportedBytes int
+	// Roles is the roles that still grant privileges on the space (or on all spaces)
+	Roles []string
+	// Deleted is true when the space has been deleted
+	Deleted bool
+}
+
+// KibanaSpaceResolveCopySavedObjectsErrorsParameter is parameters to retry the copy of objects that failed
+type KibanaSpaceResolveCopySavedObjectsErrorsParameter struct {
+	Objects           []KibanaSpaceObjectParameter                 `json:"objects"`
+	IncludeReferences bool                                         `json:"includeReferences"`
+	CreateNewCopies   bool                                         `json:"createNewCopies"`
+	Retries           map[string][]KibanaSpaceCopySavedObjectRetry `json:"retries"`
+}
+
+// KibanaSpaceCopySavedObjectRetry is the way to resolve copy error of one object in a space
+type KibanaSpaceCopySavedObjectRetry struct {
+	Type                    string `json:"type"`
+	ID                      string `json:"id"`
+	Overwrite               bool   `json:"overwrite,omitempty"`
+	DestinationID           string `json:"destinationId,omitempty"`
+	CreateNewCopy           bool   `json:"createNewCopy,omitempty"`
+	IgnoreMissingReferences bool   `json:"ignoreMissingReferences,omitempty"`
+}
+
+// KibanaSpaceCopySavedObjectsResult is the copy result for each space
+type KibanaSpaceCopySavedObjectsResult map[string]KibanaSpaceCopySavedObjectsSpaceResult
+
+// KibanaSpaceCopySavedObjectsSpaceResult is the copy result of one space
+type KibanaSpaceCopySavedObjectsSpaceResult struct {
+	Success        bool                                `json:"success"`
+	SuccessCount   int                                 `json:"successCount"`
+	SuccessResults []KibanaSpaceCopySavedObjectSuccess `json:"successResults,omitempty"`
+	Errors         []KibanaSpaceCopySavedObjectError   `json:"errors,omitempty"`
+}
+
+// KibanaSpaceCopySavedObjectMeta is the meta data of copied object
+type KibanaSpaceCopySavedObjectMeta struct {
+	Title string `json:"title,omitempty"`
+	Icon  string `json:"icon,omitempty"`
+}
+
+// KibanaSpaceCopySavedObjectSuccess is one object successfully copied
+type KibanaSpaceCopySavedObjectSuccess struct {
+	Type          string                          `json:"type"`
+	ID            string                          `json:"id"`
+	DestinationID string                          `json:"destinationId,omitempty"`
+	Overwrite     bool                            `json:"overwrite,omitempty"`
+	CreateNewCopy bool                            `json:"createNewCopy,omitempty"`
+	Meta          *KibanaSpaceCopySavedObjectMeta `json:"meta,omitempty"`
+}
+
+// KibanaSpaceCopySavedObjectError is one object that failed to be copied
+type KibanaSpaceCopySavedObjectError struct {
+	Type  string                                `json:"type"`
+	ID    string                                `json:"id"`
+	Title string                                `json:"title,omitempty"`
+	Meta  *KibanaSpaceCopySavedObjectMeta       `json:"meta,omitempty"`
+	Error KibanaSpaceCopySavedObjectErrorDetail `json:"error"`
+}
+
+// KibanaSpaceCopySavedObjectErrorDetail is the reason why the object failed to be copied
+// Type is one of conflict, ambiguous_conflict, missing_references, unsupported_type or unknown
+type KibanaSpaceCopySavedObjectErrorDetail struct {
+	Type          string                                  `json:"type"`
+	DestinationID string                                  `json:"destinationId,omitempty"`
+	Destinations  []KibanaSpaceCopySavedObjectDestination `json:"destinations,omitempty"`
+	References    []KibanaSpaceObjectParameter            `json:"references,omitempty"`
+	StatusCode    int                                     `json:"statusCode,omitempty"`
+	Message       string                                  `json:"message,omitempty"`
+}
+
+// KibanaSpaceCopySavedObjectDestination is an existing object that match the copied object
+type KibanaSpaceCopySavedObjectDestination struct {
+	ID        string `json:"id"`
+	Title     string `json:"title,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// KibanaSpaceUpdateObjectsSpacesParameter is parameters to add or remove objects from spaces
+type KibanaSpaceUpdateObjectsSpacesParameter struct {
+	Objects        []KibanaSpaceObjectParameter `json:"objects"`
+	SpacesToAdd    []string                     `json:"spacesToAdd"`
+	SpacesToRemove []string                     `json:"spacesToRemove"`
+}
+
+// KibanaSpaceObjectError is the error returned by Kibana for one object
+type KibanaSpaceObjectError struct {
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+}
+
+// KibanaSpaceObjectSpaces is the list of spaces where the object live after update
+type KibanaSpaceObjectSpaces struct {
+	Type   string                  `json:"type"`
+	ID     string                  `json:"id"`
+	Spaces []string                `json:"spaces"`
+	Error  *KibanaSpaceObjectError `json:"error,omitempty"`
+}
+
+// KibanaSpaceUpdateObjectsSpacesResult is the result of update objects spaces
+type KibanaSpaceUpdateObjectsSpacesResult struct {
+	Objects []KibanaSpaceObjectSpaces `json:"objects"`
+}
+
+// KibanaSpaceGetShareableReferencesParameter is parameters to get the shareable references of objects
+type KibanaSpaceGetShareableReferencesParameter struct {
+	Objects []KibanaSpaceObjectParameter `json:"objects"`
+}
+
+// KibanaSpaceShareableReference is one object that need to be shared with the requested objects
+type KibanaSpaceShareableReference struct {
+	Type                      string                       `json:"type"`
+	ID                        string                       `json:"id"`
+	Spaces                    []string                     `json:"spaces"`
+	InboundReferences         []KibanaSavedObjectReference `json:"inboundReferences"`
+	IsMissing                 bool                         `json:"isMissing,omitempty"`
+	SpacesWithMatchingAliases []string                     `json:"spacesWithMatchingAliases,omitempty"`
+	SpacesWithMatchingOrigins []string                     `json:"spacesWithMatchingOrigins,omitempty"`
+}
+
+// KibanaSpaceShareableReferences is the result of get shareable references
+type KibanaSpaceShareableReferences struct {
+	Objects []KibanaSpaceShareableReference `json:"objects"`
+}
+
+// KibanaSpaceLegacyURLAlias is the legacy URL alias of object that was converted when sharing it
+// TargetID and Disabled are only set by FindLegacyURLAliases and are not sent to Kibana
+type KibanaSpaceLegacyURLAlias struct {
+	TargetSpace string `json:"targetSpace"`
+	TargetType  string `json:"targetType"`
+	SourceID    string `json:"sourceId"`
+	TargetID    string `json:"-"`
+	Disabled    bool   `json:"-"`
+}
+
 // KibanaSpaceGet permit to get space
 type KibanaSpaceGet func(id string) (*KibanaSpace, error)
 
 // KibanaSpaceList permit to get all spaces
-type KibanaSpaceList func() (KibanaSpaces, error)
+type KibanaSpaceList func(optionalParameters *OptionalListSpacesParameters) (KibanaSpaces, error)
 
 // KibanaSpaceCreate permit to create space
 type KibanaSpaceCreate func(kibanaSpace *KibanaSpace) (*KibanaSpace, error)
@@ -54,11 +261,29 @@ type KibanaSpaceCreate func(kibanaSpace *KibanaSpace) (*KibanaSpace, error)
 // KibanaSpaceDelete permit to delete space
 type KibanaSpaceDelete func(id string) error
 
+// KibanaSpaceSafeDelete permit to backup space objects, report the roles that use it and delete space
+type KibanaSpaceSafeDelete func(id string, backup io.Writer, optionalParameters *OptionalSafeDeleteParameters) (*KibanaSpaceSafeDeleteResult, error)
+
 // KibanaSpaceUpdate permit to update space
 type KibanaSpaceUpdate func(kibanaSpace *KibanaSpace) (*KibanaSpace, error)
 
 // KibanaSpaceCopySavedObjects permit to copy dashboad between space
-type KibanaSpaceCopySavedObjects func(parameter *KibanaSpaceCopySavedObjectParameter, spaceOrigin string) error
+type KibanaSpaceCopySavedObjects func(parameter *KibanaSpaceCopySavedObjectParameter, spaceOrigin string) (KibanaSpaceCopySavedObjectsResult, error)
+
+// KibanaSpaceResolveCopySavedObjectsErrors permit to retry the copy of objects that failed between space
+type KibanaSpaceResolveCopySavedObjectsErrors func(parameter *KibanaSpaceResolveCopySavedObjectsErrorsParameter, spaceOrigin string) (KibanaSpaceCopySavedObjectsResult, error)
+
+// KibanaSpaceUpdateObjectsSpaces permit to share objects between spaces
+type KibanaSpaceUpdateObjectsSpaces func(parameter *KibanaSpaceUpdateObjectsSpacesParameter, kibanaSpace string) (*KibanaSpaceUpdateObjectsSpacesResult, error)
+
+// KibanaSpaceGetShareableReferences permit to get the objects and their references that must be shared together
+type KibanaSpaceGetShareableReferences func(parameter *KibanaSpaceGetShareableReferencesParameter, kibanaSpace string) (*KibanaSpaceShareableReferences, error)
+
+// KibanaSpaceDisableLegacyURLAliases permit to disable legacy URL aliases
+type KibanaSpaceDisableLegacyURLAliases func(aliases []KibanaSpaceLegacyURLAlias) error
+
+// KibanaSpaceFindLegacyURLAliases permit to find legacy URL aliases
+type KibanaSpaceFindLegacyURLAliases func(targetSpace string, targetType string) ([]KibanaSpaceLegacyURLAlias, error)
 
 // String permit to return KibanaSpace object as JSON string
 func (k *KibanaSpace) String() string {
@@ -66,6 +291,62 @@ func (k *KibanaSpace) String() string {
 	return string(json)
 }
 
+// MarshalJSON permit to add the extra attributes on KibanaSpace JSON
+func (k KibanaSpace) MarshalJSON() ([]byte, error) {
+	k.AuthorizedPurposes = nil
+	data, err := json.Marshal(kibanaSpaceJSON(k))
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeExtraFields(data, k.Extra)
+}
+
+// UnmarshalJSON permit to keep unknown attributes on Extra
+func (k *KibanaSpace) UnmarshalJSON(data []byte) error {
+	space := kibanaSpaceJSON{}
+	if err := json.Unmarshal(data, &space); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, kibanaSpaceFields)
+	if err != nil {
+		return err
+	}
+	space.Extra = extra
+
+	*k = KibanaSpace(space)
+	return nil
+}
+
+// Validate permit to check the space attributes before send it to Kibana
+func (k *KibanaSpace) Validate() error {
+	if k.ID == "" {
+		return NewAPIError(600, "You must provide kibana space ID")
+	}
+	if !kibanaSpaceIDRegexp.MatchString(k.ID) {
+		return NewAPIError(600, "Kibana space ID %s must contain only lowercase letters, numbers, '_' and '-'", k.ID)
+	}
+	if k.Name == "" {
+		return NewAPIError(600, "You must provide kibana space name")
+	}
+	if k.Color != "" && !kibanaSpaceColorRegexp.MatchString(k.Color) {
+		return NewAPIError(600, "Kibana space color %s must be a 6 digit hex color, starting with a #", k.Color)
+	}
+	if len([]rune(k.Initials)) > 2 {
+		return NewAPIError(600, "Kibana space initials %s must have 2 characters maximum", k.Initials)
+	}
+	if k.ImageURL != "" && !strings.HasPrefix(k.ImageURL, "data:image/") {
+		return NewAPIError(600, "Kibana space image URL must be a data:image/ URL")
+	}
+	switch k.Solution {
+	case "", KibanaSpaceSolutionSecurity, KibanaSpaceSolutionObservability, KibanaSpaceSolutionSearch, KibanaSpaceSolutionClassic:
+	default:
+		return NewAPIError(600, "Kibana space solution %s is not supported", k.Solution)
+	}
+
+	return nil
+}
+
 // newKibanaSpaceGetFunc permit to get the kibana space with it id
 func newKibanaSpaceGetFunc(c *resty.Client) KibanaSpaceGet {
 	return func(id string) (*KibanaSpace, error) {
@@ -102,10 +383,29 @@ func newKibanaSpaceGetFunc(c *resty.Client) KibanaSpaceGet {
 
 // newKibanaSpaceListFunc permit to get all Kibana space
 func newKibanaSpaceListFunc(c *resty.Client) KibanaSpaceList {
-	return func() (KibanaSpaces, error) {
+	return func(optionalParameters *OptionalListSpacesParameters) (KibanaSpaces, error) {
+
+		queryParams := map[string]string{}
+		if optionalParameters != nil {
+			log.Debug("Purpose: ", optionalParameters.Purpose)
+			log.Debug("IncludeAuthorizedPurposes: ", optionalParameters.IncludeAuthorizedPurposes)
+			if optionalParameters.Purpose != "" && optionalParameters.IncludeAuthorizedPurposes {
+				return nil, NewAPIError(600, "You can't use purpose and include authorized purposes at the same time")
+			}
+			switch optionalParameters.Purpose {
+			case "":
+			case KibanaSpacePurposeAny, KibanaSpacePurposeCopySavedObjectsIntoSpace, KibanaSpacePurposeShareSavedObjectsIntoSpace:
+				queryParams["purpose"] = optionalParameters.Purpose
+			default:
+				return nil, NewAPIError(600, "Purpose %s is not supported", optionalParameters.Purpose)
+			}
+			if optionalParameters.IncludeAuthorizedPurposes {
+				queryParams["include_authorized_purposes"] = "true"
+			}
+		}
 
 		path := fmt.Sprintf("%s/space", basePathKibanaSpace)
-		resp, err := c.R().Get(path)
+		resp, err := c.R().SetQueryParams(queryParams).Get(path)
 		if err != nil {
 			return nil, err
 		}
@@ -133,6 +433,9 @@ func newKibanaSpaceCreateFunc(c *resty.Client) KibanaSpaceCreate {
 			return nil, NewAPIError(600, "You must provide kibana space object")
 		}
 		log.Debug("KibanaSpace: ", kibanaSpace)
+		if err := kibanaSpace.Validate(); err != nil {
+			return nil, err
+		}
 
 		jsonData, err := json.Marshal(kibanaSpace)
 		if err != nil {
@@ -161,11 +464,12 @@ func newKibanaSpaceCreateFunc(c *resty.Client) KibanaSpaceCreate {
 }
 
 // newKibanaSpaceCopySavedObjectsFunc permit to copy extings objects from user space to another userSpace
+// When the copy failed on some spaces, it return the result and an error that list objects in error
 func newKibanaSpaceCopySavedObjectsFunc(c *resty.Client) KibanaSpaceCopySavedObjects {
-	return func(parameter *KibanaSpaceCopySavedObjectParameter, spaceOrigin string) error {
+	return func(parameter *KibanaSpaceCopySavedObjectParameter, spaceOrigin string) (KibanaSpaceCopySavedObjectsResult, error) {
 
 		if parameter == nil {
-			return NewAPIError(600, "You must provide parameter to copy existing objects on other user spaces")
+			return nil, NewAPIError(600, "You must provide parameter to copy existing objects on other user spaces")
 		}
 		log.Debug("Parameter: ", parameter)
 		log.Debug("SpaceOrigin: ", spaceOrigin)
@@ -176,34 +480,219 @@ func newKibanaSpaceCopySavedObjectsFunc(c *resty.Client) KibanaSpaceCopySavedObj
 		} else {
 			path = fmt.Sprintf("/s/%s%s/_copy_saved_objects", spaceOrigin, basePathKibanaSpace)
 		}
-		jsonData, err := json.Marshal(parameter)
+
+		return postKibanaSpaceCopySavedObjects(c, path, parameter)
+	}
+
+}
+
+// newKibanaSpaceResolveCopySavedObjectsErrorsFunc permit to overwrite or skip objects that failed to be copied from user space to another userSpace
+// When the copy failed on some spaces, it return the result and an error that list objects in error
+func newKibanaSpaceResolveCopySavedObjectsErrorsFunc(c *resty.Client) KibanaSpaceResolveCopySavedObjectsErrors {
+	return func(parameter *KibanaSpaceResolveCopySavedObjectsErrorsParameter, spaceOrigin string) (KibanaSpaceCopySavedObjectsResult, error) {
+
+		if parameter == nil {
+			return nil, NewAPIError(600, "You must provide parameter to resolve copy errors on other user spaces")
+		}
+		if len(parameter.Retries) == 0 {
+			return nil, NewAPIError(600, "You must provide retries for one or more user spaces")
+		}
+		log.Debug("Parameter: ", parameter)
+		log.Debug("SpaceOrigin: ", spaceOrigin)
+
+		var path string
+		if spaceOrigin == "" || spaceOrigin == "default" {
+			path = fmt.Sprintf("%s/_resolve_copy_saved_objects_errors", basePathKibanaSpace)
+		} else {
+			path = fmt.Sprintf("/s/%s%s/_resolve_copy_saved_objects_errors", spaceOrigin, basePathKibanaSpace)
+		}
+
+		return postKibanaSpaceCopySavedObjects(c, path, parameter)
+	}
+
+}
+
+// postKibanaSpaceCopySavedObjects send the copy request and read the result of each space
+func postKibanaSpaceCopySavedObjects(c *resty.Client, path string, parameter interface{}) (KibanaSpaceCopySavedObjectsResult, error) {
+	jsonData, err := json.Marshal(parameter)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.R().SetBody(jsonData).Post(path)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("Response: ", resp)
+	if resp.StatusCode() >= 300 {
+		return nil, NewAPIError(resp.StatusCode(), resp.Status())
+	}
+	result := make(KibanaSpaceCopySavedObjectsResult)
+	err = json.Unmarshal(resp.Body(), &result)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Result: ", result)
+
+	var errors []string
+	for name, spaceResult := range result {
+		if spaceResult.Success {
+			continue
+		}
+		if len(spaceResult.Errors) == 0 {
+			errors = append(errors, fmt.Sprintf("Error to process user space %s", name))
+		}
+		for _, objectError := range spaceResult.Errors {
+			errors = append(errors, fmt.Sprintf("Error to process user space %s: object %s/%s failed with %s error", name, objectError.Type, objectError.ID, objectError.Error.Type))
+		}
+	}
+	if len(errors) > 0 {
+		sort.Strings(errors)
+		return result, NewAPIError(600, strings.Join(errors, "\n"))
+	}
+
+	return result, nil
+}
+
+// newKibanaSpaceUpdateObjectsSpacesFunc permit to add or remove existing objects from user spaces without copy them
+// When some objects failed, it return the result and an error with the list of objects in error
+func newKibanaSpaceUpdateObjectsSpacesFunc(c *resty.Client) KibanaSpaceUpdateObjectsSpaces {
+	return func(parameter *KibanaSpaceUpdateObjectsSpacesParameter, kibanaSpace string) (*KibanaSpaceUpdateObjectsSpacesResult, error) {
+
+		if parameter == nil {
+			return nil, NewAPIError(600, "You must provide parameter to update objects spaces")
+		}
+		if len(parameter.Objects) == 0 {
+			return nil, NewAPIError(600, "You must provide one or more objects")
+		}
+		if len(parameter.SpacesToAdd) == 0 && len(parameter.SpacesToRemove) == 0 {
+			return nil, NewAPIError(600, "You must provide spaces to add or spaces to remove")
+		}
+		log.Debug("Parameter: ", parameter)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		// Kibana expect empty array and not null
+		payload := *parameter
+		if payload.SpacesToAdd == nil {
+			payload.SpacesToAdd = []string{}
+		}
+		if payload.SpacesToRemove == nil {
+			payload.SpacesToRemove = []string{}
+		}
+
+		var path string
+		if kibanaSpace == "" || kibanaSpace == "default" {
+			path = fmt.Sprintf("%s/_update_objects_spaces", basePathKibanaSpace)
+		} else {
+			path = fmt.Sprintf("/s/%s%s/_update_objects_spaces", kibanaSpace, basePathKibanaSpace)
+		}
+		jsonData, err := json.Marshal(payload)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		resp, err := c.R().SetBody(jsonData).Post(path)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		log.Debug("Response: ", resp)
 		if resp.StatusCode() >= 300 {
-			return NewAPIError(resp.StatusCode(), resp.Status())
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
 		}
-		data := make(map[string]interface{})
-		err = json.Unmarshal(resp.Body(), &data)
+		result := &KibanaSpaceUpdateObjectsSpacesResult{}
+		err = json.Unmarshal(resp.Body(), result)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		log.Debug("Response: ", data)
+		log.Debug("Result: ", result)
 
 		var errors []string
-		for name, object := range data {
-			if !object.(map[string]interface{})["success"].(bool) {
-				errors = append(errors, fmt.Sprintf("Error to process user space %s", name))
+		for _, object := range result.Objects {
+			if object.Error != nil {
+				errors = append(errors, fmt.Sprintf("Error to update spaces of object %s/%s: %s", object.Type, object.ID, object.Error.Message))
 			}
 		}
 		if len(errors) > 0 {
-			return NewAPIError(500, strings.Join(errors, "\n"))
+			return result, NewAPIError(600, strings.Join(errors, "\n"))
+		}
+
+		return result, nil
+	}
+
+}
+
+// newKibanaSpaceGetShareableReferencesFunc permit to get the references graph of objects before sharing them
+func newKibanaSpaceGetShareableReferencesFunc(c *resty.Client) KibanaSpaceGetShareableReferences {
+	return func(parameter *KibanaSpaceGetShareableReferencesParameter, kibanaSpace string) (*KibanaSpaceShareableReferences, error) {
+
+		if parameter == nil {
+			return nil, NewAPIError(600, "You must provide parameter to get shareable references")
+		}
+		if len(parameter.Objects) == 0 {
+			return nil, NewAPIError(600, "You must provide one or more objects")
+		}
+		log.Debug("Parameter: ", parameter)
+		log.Debug("KibanaSpace: ", kibanaSpace)
+
+		var path string
+		if kibanaSpace == "" || kibanaSpace == "default" {
+			path = fmt.Sprintf("%s/_get_shareable_references", basePathKibanaSpace)
+		} else {
+			path = fmt.Sprintf("/s/%s%s/_get_shareable_references", kibanaSpace, basePathKibanaSpace)
+		}
+		jsonData, err := json.Marshal(parameter)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.R().SetBody(jsonData).Post(path)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return nil, NewAPIError(resp.StatusCode(), resp.Status())
+		}
+		shareableReferences := &KibanaSpaceShareableReferences{}
+		err = json.Unmarshal(resp.Body(), shareableReferences)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("ShareableReferences: ", shareableReferences)
+
+		return shareableReferences, nil
+	}
+
+}
+
+// newKibanaSpaceDisableLegacyURLAliasesFunc permit to disable legacy URL aliases, so old URL not redirect anymore on the new object
+func newKibanaSpaceDisableLegacyURLAliasesFunc(c *resty.Client) KibanaSpaceDisableLegacyURLAliases {
+	return func(aliases []KibanaSpaceLegacyURLAlias) error {
+
+		if len(aliases) == 0 {
+			return NewAPIError(600, "You must provide one or more legacy URL aliases")
+		}
+		for _, alias := range aliases {
+			if alias.TargetSpace == "" || alias.TargetType == "" || alias.SourceID == "" {
+				return NewAPIError(600, "You must provide the target space, the target type and the source ID of each legacy URL alias")
+			}
+		}
+		log.Debug("Aliases: ", aliases)
+
+		jsonData, err := json.Marshal(map[string]interface{}{
+			"aliases": aliases,
+		})
+		if err != nil {
+			return err
+		}
+		path := fmt.Sprintf("%s/_disable_legacy_url_aliases", basePathKibanaSpace)
+		resp, err := c.R().SetBody(jsonData).Post(path)
+		if err != nil {
+			return err
+		}
+		log.Debug("Response: ", resp)
+		if resp.StatusCode() >= 300 {
+			return NewAPIError(resp.StatusCode(), resp.Status())
 		}
 
 		return nil
@@ -211,6 +700,59 @@ func newKibanaSpaceCopySavedObjectsFunc(c *resty.Client) KibanaSpaceCopySavedObj
 
 }
 
+// newKibanaSpaceFindLegacyURLAliasesFunc permit to find the legacy URL aliases saved objects that are not disabled.
+// It filter them on target space and target type when provided.
+func newKibanaSpaceFindLegacyURLAliasesFunc(c *resty.Client) KibanaSpaceFindLegacyURLAliases {
+	return func(targetSpace string, targetType string) ([]KibanaSpaceLegacyURLAlias, error) {
+
+		log.Debug("TargetSpace: ", targetSpace)
+		log.Debug("TargetType: ", targetType)
+
+		find := newKibanaSavedObjectFindFunc(c)
+		aliases := make([]KibanaSpaceLegacyURLAlias, 0)
+		parameters := &OptionalFindParameters{
+			ObjectsPerPage: 1000,
+			Page:           1,
+		}
+		for {
+			data, err := find("legacy-url-alias", "", parameters)
+			if err != nil {
+				return nil, err
+			}
+			if data == nil {
+				return aliases, nil
+			}
+
+			savedObjects, _ := data["saved_objects"].([]interface{})
+			for _, savedObject := range savedObjects {
+				object, _ := savedObject.(map[string]interface{})
+				attributes, _ := object["attributes"].(map[string]interface{})
+				alias := KibanaSpaceLegacyURLAlias{}
+				alias.TargetSpace, _ = attributes["targetNamespace"].(string)
+				alias.TargetType, _ = attributes["targetType"].(string)
+				alias.SourceID, _ = attributes["sourceId"].(string)
+				alias.TargetID, _ = attributes["targetId"].(string)
+				alias.Disabled, _ = attributes["disabled"].(bool)
+
+				if alias.Disabled || (targetSpace != "" && alias.TargetSpace != targetSpace) || (targetType != "" && alias.TargetType != targetType) {
+					continue
+				}
+				aliases = append(aliases, alias)
+			}
+
+			total, _ := data["total"].(float64)
+			if len(savedObjects) == 0 || parameters.Page*parameters.ObjectsPerPage >= int(total) {
+				break
+			}
+			parameters.Page++
+		}
+		log.Debug("Aliases: ", aliases)
+
+		return aliases, nil
+	}
+
+}
+
 // newKibanaSpaceDeleteFunc permit to delete the kubana space wiht it id
 func newKibanaSpaceDeleteFunc(c *resty.Client) KibanaSpaceDelete {
 	return func(id string) error {
@@ -238,6 +780,215 @@ func newKibanaSpaceDeleteFunc(c *resty.Client) KibanaSpaceDelete {
 
 }
 
+// newKibanaSpaceSafeDeleteFunc permit to export all objects of space on backup, list roles with privileges on it
+// and delete it only if the delete is confirmed
+func newKibanaSpaceSafeDeleteFunc(c *resty.Client) KibanaSpaceSafeDelete {
+	return func(id string, backup io.Writer, optionalParameters *OptionalSafeDeleteParameters) (*KibanaSpaceSafeDeleteResult, error) {
+
+		if id == "" {
+			return nil, NewAPIError(600, "You must provide kibana space ID")
+		}
+		if id == "default" {
+			return nil, NewAPIError(600, "You can't delete the default kibana space")
+		}
+		if backup == nil {
+			return nil, NewAPIError(600, "You must provide the writer to backup the kibana space objects")
+		}
+		if optionalParameters == nil {
+			optionalParameters = &OptionalSafeDeleteParameters{}
+		}
+		log.Debug("ID: ", id)
+		log.Debug("Confirm: ", optionalParameters.Confirm)
+		log.Debug("ObjectTypes: ", optionalParameters.ObjectTypes)
+
+		kibanaSpace, err := newKibanaSpaceGetFunc(c)(id)
+		if err != nil {
+			return nil, err
+		}
+		if kibanaSpace == nil {
+			return nil, NewAPIError(404, "Kibana space %s not found", id)
+		}
+
+		// Only the types registered on Kibana can be exported, else the export fail
+		exportableTypes, err := getKibanaSavedObjectExportableTypes(c)
+		if err != nil {
+			return nil, err
+		}
+		objectTypes := optionalParameters.ObjectTypes
+		if len(objectTypes) == 0 {
+			for _, exportableType := range exportableTypes {
+				objectTypes = append(objectTypes, exportableType.Name)
+			}
+		}
+		log.Debug("ObjectTypes: ", objectTypes)
+
+		// Objects of types not exported would be lost
+		missingTypes := make([]string, 0)
+		for _, exportableType := range exportableTypes {
+			if !exportableType.Hidden && !stringInSlice(exportableType.Name, objectTypes) {
+				missingTypes = append(missingTypes, exportableType.Name)
+			}
+		}
+		if len(missingTypes) > 0 {
+			total, err := countKibanaSpaceObjects(c, id, missingTypes)
+			if err != nil {
+				return nil, err
+			}
+			if total > 0 {
+				return nil, NewAPIError(600, "Kibana space %s has %d objects of types not exported, you must export all types from %v", id, total, missingTypes)
+			}
+		}
+
+		// Backup all objects
+		data, err := exportKibanaSpaceObjects(c, id, objectTypes)
+		if err != nil {
+			return nil, err
+		}
+		result := &KibanaSpaceSafeDeleteResult{}
+		result.ExportedBytes, err = backup.Write(data)
+		if err != nil {
+			return result, err
+		}
+
+		// Search roles that grant privileges on space
+		kibanaRoles, err := newKibanaRoleManagementListFunc(c)()
+		if err != nil {
+			return result, err
+		}
+		for _, kibanaRole := range kibanaRoles {
+			for _, kibana := range kibanaRole.Kibana {
+				if stringInSlice(id, kibana.Spaces) || stringInSlice("*", kibana.Spaces) {
+					result.Roles = append(result.Roles, kibanaRole.Name)
+					break
+				}
+			}
+		}
+		log.Debug("Roles: ", result.Roles)
+
+		if !optionalParameters.Confirm {
+			return result, NewAPIError(600, "Kibana space %s not deleted, you must confirm the delete", id)
+		}
+
+		if err = newKibanaSpaceDeleteFunc(c)(id); err != nil {
+			return result, err
+		}
+		result.Deleted = true
+
+		return result, nil
+	}
+
+}
+
+// kibanaSavedObjectType is the saved object type that Kibana can export
+type kibanaSavedObjectType struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden"`
+}
+
+// getKibanaSavedObjectExportableTypes return the saved object types that Kibana can import and export.
+// Kibana before 8.0 return the type names, and the type objects after
+func getKibanaSavedObjectExportableTypes(c *resty.Client) ([]kibanaSavedObjectType, error) {
+	resp, err := c.R().Get(basePathKibanaSavedObjectTypes)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Response: ", resp)
+	if resp.StatusCode() >= 300 {
+		return nil, NewAPIError(resp.StatusCode(), resp.Status())
+	}
+
+	dataResponse := struct {
+		Types []json.RawMessage `json:"types"`
+	}{}
+	if err = json.Unmarshal(resp.Body(), &dataResponse); err != nil {
+		return nil, err
+	}
+	objectTypes := make([]kibanaSavedObjectType, 0, len(dataResponse.Types))
+	for _, rawType := range dataResponse.Types {
+		objectType := kibanaSavedObjectType{}
+		if err = json.Unmarshal(rawType, &objectType.Name); err != nil {
+			if err = json.Unmarshal(rawType, &objectType); err != nil {
+				return nil, err
+			}
+		}
+		objectTypes = append(objectTypes, objectType)
+	}
+	log.Debug("ExportableTypes: ", objectTypes)
+
+	return objectTypes, nil
+}
+
+// countKibanaSpaceObjects return the number of objects of types in space
+func countKibanaSpaceObjects(c *resty.Client, id string, objectTypes []string) (int, error) {
+	path := fmt.Sprintf("/s/%s%s/_find", id, basePathKibanaSavedObject)
+	resp, err := c.R().
+		SetQueryParamsFromValues(map[string][]string{
+			"type":     objectTypes,
+			"per_page": {"0"},
+		}).
+		Get(path)
+	if err != nil {
+		return 0, err
+	}
+	log.Debug("Response: ", resp)
+	if resp.StatusCode() >= 300 {
+		return 0, NewAPIError(resp.StatusCode(), resp.Status())
+	}
+
+	dataResponse := struct {
+		Total int `json:"total"`
+	}{}
+	if err = json.Unmarshal(resp.Body(), &dataResponse); err != nil {
+		return 0, err
+	}
+
+	return dataResponse.Total, nil
+}
+
+// exportKibanaSpaceObjects export objects of types in space with their references.
+// It fail if Kibana exclude objects from export, so the backup is complete
+func exportKibanaSpaceObjects(c *resty.Client, id string, objectTypes []string) ([]byte, error) {
+	payload := map[string]interface{}{
+		"type":                  objectTypes,
+		"includeReferencesDeep": true,
+		"excludeExportDetails":  false,
+	}
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/s/%s%s/_export", id, basePathKibanaSavedObject)
+	resp, err := c.R().SetBody(jsonData).Post(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Response: ", resp)
+	if resp.StatusCode() >= 300 {
+		return nil, NewAPIError(resp.StatusCode(), resp.Status())
+	}
+
+	// The last line is the export details
+	data := bytes.TrimRight(resp.Body(), "\n")
+	details := struct {
+		ExportedCount        *int `json:"exportedCount"`
+		ExcludedObjectsCount int  `json:"excludedObjectsCount"`
+		ExcludedObjects      []struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"excludedObjects"`
+	}{}
+	index := bytes.LastIndexByte(data, '\n')
+	if err = json.Unmarshal(data[index+1:], &details); err != nil || details.ExportedCount == nil {
+		return nil, NewAPIError(600, "Kibana space %s export has no details, the backup can't be checked", id)
+	}
+	if details.ExcludedObjectsCount > 0 {
+		return nil, NewAPIError(600, "Kibana space %s export is incomplete, %d objects excluded: %v", id, details.ExcludedObjectsCount, details.ExcludedObjects)
+	}
+	log.Debugf("Exported %d objects", *details.ExportedCount)
+
+	return data[:index+1], nil
+}
+
 // newKibanaSpaceUpdateFunc permit to update the Kibana space
 func newKibanaSpaceUpdateFunc(c *resty.Client) KibanaSpaceUpdate {
 	return func(kibanaSpace *KibanaSpace) (*KibanaSpace, error) {
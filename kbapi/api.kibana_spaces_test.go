@@ -1,15 +1,40 @@
 package kbapi
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 )
 
 func (s *KBAPITestSuite) TestKibanaSpaces() {
 
 	// List kibana space
-	kibanaSpaces, err := s.API.KibanaSpaces.List()
+	kibanaSpaces, err := s.API.KibanaSpaces.List(nil)
+	assert.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), kibanaSpaces)
+
+	// List kibana space with authorized purposes
+	kibanaSpaces, err = s.API.KibanaSpaces.List(&OptionalListSpacesParameters{IncludeAuthorizedPurposes: true})
 	assert.NoError(s.T(), err)
 	assert.NotEmpty(s.T(), kibanaSpaces)
+	assert.NotNil(s.T(), kibanaSpaces[0].AuthorizedPurposes)
+	assert.True(s.T(), kibanaSpaces[0].AuthorizedPurposes.ShareSavedObjectsIntoSpace)
+
+	// List kibana space where objects can be shared
+	kibanaSpaces, err = s.API.KibanaSpaces.List(&OptionalListSpacesParameters{Purpose: KibanaSpacePurposeShareSavedObjectsIntoSpace})
+	assert.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), kibanaSpaces)
+
+	// List kibana space with purpose and authorized purposes
+	_, err = s.API.KibanaSpaces.List(&OptionalListSpacesParameters{Purpose: KibanaSpacePurposeAny, IncludeAuthorizedPurposes: true})
+	assert.Error(s.T(), err)
 
 	// Get the default Space
 	kibanaSpace, err := s.API.KibanaSpaces.Get(kibanaSpaces[0].ID)
@@ -22,16 +47,28 @@ func (s *KBAPITestSuite) TestKibanaSpaces() {
 		ID:          "test",
 		Name:        "test",
 		Description: "My test",
+		Color:       "#AABBCC",
+		Initials:    "TE",
+		ImageURL:    "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==",
 	}
 	kibanaSpace, err = s.KibanaSpaces.Create(kibanaSpace)
 	assert.NoError(s.T(), err)
 	assert.NotEmpty(s.T(), kibanaSpace.ID)
 
-	// Update space
+	// Create space with bad color
+	_, err = s.KibanaSpaces.Create(&KibanaSpace{ID: "test-bad", Name: "test", Color: "red"})
+	assert.Error(s.T(), err)
+
+	// Update space keep the attributes not modified
+	kibanaSpace, err = s.KibanaSpaces.Get(kibanaSpace.ID)
+	assert.NoError(s.T(), err)
 	kibanaSpace.Name = "test2"
 	kibanaSpace, err = s.KibanaSpaces.Update(kibanaSpace)
 	assert.NoError(s.T(), err)
 	assert.Equal(s.T(), "test2", kibanaSpace.Name)
+	assert.Equal(s.T(), "#AABBCC", kibanaSpace.Color)
+	assert.Equal(s.T(), "TE", kibanaSpace.Initials)
+	assert.NotEmpty(s.T(), kibanaSpace.ImageURL)
 
 	// Copy object on space
 	parameter := &KibanaSpaceCopySavedObjectParameter{
@@ -46,8 +83,132 @@ func (s *KBAPITestSuite) TestKibanaSpaces() {
 			},
 		},
 	}
-	err = s.KibanaSpaces.CopySavedObjects(parameter, "")
+	copyResult, err := s.KibanaSpaces.CopySavedObjects(parameter, "")
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), copyResult["test"].Success)
+
+	// Copy object that already exist on space
+	_, err = s.KibanaSavedObject.Create(map[string]interface{}{"attributes": map[string]interface{}{"title": "test-copy-*"}}, "index-pattern", "test-copy", true, "default", nil)
+	assert.NoError(s.T(), err)
+	parameter = &KibanaSpaceCopySavedObjectParameter{
+		Spaces:            []string{"test"},
+		IncludeReferences: true,
+		Objects: []KibanaSpaceObjectParameter{
+			{
+				Type: "index-pattern",
+				ID:   "test-copy",
+			},
+		},
+	}
+	copyResult, err = s.KibanaSpaces.CopySavedObjects(parameter, "")
 	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, copyResult["test"].SuccessCount)
+	assert.NotEmpty(s.T(), copyResult["test"].SuccessResults[0].DestinationID)
+	copyResult, err = s.KibanaSpaces.CopySavedObjects(parameter, "")
+	assert.Error(s.T(), err)
+	assert.False(s.T(), copyResult["test"].Success)
+	assert.Equal(s.T(), "conflict", copyResult["test"].Errors[0].Error.Type)
+
+	// Resolve copy conflict
+	copyResult, err = s.KibanaSpaces.ResolveCopySavedObjectsErrors(&KibanaSpaceResolveCopySavedObjectsErrorsParameter{
+		Objects:           parameter.Objects,
+		IncludeReferences: true,
+		Retries: map[string][]KibanaSpaceCopySavedObjectRetry{
+			"test": {
+				{
+					Type:          "index-pattern",
+					ID:            "test-copy",
+					Overwrite:     true,
+					DestinationID: copyResult["test"].Errors[0].Error.DestinationID,
+				},
+			},
+		},
+	}, "")
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), copyResult["test"].Success)
+	err = s.KibanaSavedObject.Delete("index-pattern", "test-copy", "default")
+	assert.NoError(s.T(), err)
+
+	// Share index pattern with space
+	_, err = s.KibanaSavedObject.Create(map[string]interface{}{"attributes": map[string]interface{}{"title": "test-share-*"}}, "index-pattern", "test-share", true, "default", nil)
+	assert.NoError(s.T(), err)
+	updateObjectsSpacesParameter := &KibanaSpaceUpdateObjectsSpacesParameter{
+		Objects: []KibanaSpaceObjectParameter{
+			{
+				Type: "index-pattern",
+				ID:   "test-share",
+			},
+		},
+		SpacesToAdd: []string{"test"},
+	}
+	updateObjectsSpacesResult, err := s.KibanaSpaces.UpdateObjectsSpaces(updateObjectsSpacesParameter, "")
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), updateObjectsSpacesResult)
+	assert.ElementsMatch(s.T(), []string{"default", "test"}, updateObjectsSpacesResult.Objects[0].Spaces)
+
+	// Get shareable references
+	shareableReferences, err := s.KibanaSpaces.GetShareableReferences(&KibanaSpaceGetShareableReferencesParameter{
+		Objects: updateObjectsSpacesParameter.Objects,
+	}, "")
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), shareableReferences)
+	assert.Equal(s.T(), "test-share", shareableReferences.Objects[0].ID)
+	assert.ElementsMatch(s.T(), []string{"default", "test"}, shareableReferences.Objects[0].Spaces)
+
+	// Unshare index pattern
+	updateObjectsSpacesParameter.SpacesToAdd = nil
+	updateObjectsSpacesParameter.SpacesToRemove = []string{"test"}
+	updateObjectsSpacesResult, err = s.KibanaSpaces.UpdateObjectsSpaces(updateObjectsSpacesParameter, "")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"default"}, updateObjectsSpacesResult.Objects[0].Spaces)
+	err = s.KibanaSavedObject.Delete("index-pattern", "test-share", "default")
+	assert.NoError(s.T(), err)
+
+	// Disable legacy URL alias
+	err = s.KibanaSpaces.DisableLegacyURLAliases([]KibanaSpaceLegacyURLAlias{
+		{
+			TargetSpace: "test",
+			TargetType:  "dashboard",
+			SourceID:    "test-legacy",
+		},
+	})
+	assert.NoError(s.T(), err)
+	err = s.KibanaSpaces.DisableLegacyURLAliases(nil)
+	assert.Error(s.T(), err)
+
+	// Safe delete space without confirmation
+	_, err = s.API.KibanaRoleManagement.CreateOrUpdate(&KibanaRole{
+		Name: "test-space",
+		Kibana: []KibanaRoleKibana{
+			{
+				Base:   []string{"read"},
+				Spaces: []string{"test"},
+			},
+		},
+	})
+	assert.NoError(s.T(), err)
+	backup := &bytes.Buffer{}
+	safeDeleteResult, err := s.KibanaSpaces.SafeDelete(kibanaSpace.ID, backup, nil)
+	assert.Error(s.T(), err)
+	assert.False(s.T(), safeDeleteResult.Deleted)
+	assert.Contains(s.T(), safeDeleteResult.Roles, "test-space")
+	assert.NotEmpty(s.T(), backup.Bytes())
+	assert.Equal(s.T(), backup.Len(), safeDeleteResult.ExportedBytes)
+	kibanaSpace, err = s.KibanaSpaces.Get(kibanaSpace.ID)
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), kibanaSpace)
+	err = s.API.KibanaRoleManagement.Delete("test-space")
+	assert.NoError(s.T(), err)
+
+	// Safe delete space with confirmation
+	_, err = s.KibanaSpaces.Create(&KibanaSpace{ID: "test-safe", Name: "test-safe"})
+	assert.NoError(s.T(), err)
+	backup.Reset()
+	safeDeleteResult, err = s.KibanaSpaces.SafeDelete("test-safe", backup, &OptionalSafeDeleteParameters{Confirm: true})
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), safeDeleteResult.Deleted)
+	_, err = s.KibanaSpaces.SafeDelete("default", backup, &OptionalSafeDeleteParameters{Confirm: true})
+	assert.Error(s.T(), err)
 
 	// Delete space
 	err = s.KibanaSpaces.Delete(kibanaSpace.ID)
@@ -57,3 +218,197 @@ func (s *KBAPITestSuite) TestKibanaSpaces() {
 	assert.Nil(s.T(), kibanaSpace)
 
 }
+
+func TestKibanaSpaceExtraAttributes(t *testing.T) {
+
+	// Unknown attributes are kept on Extra
+	kibanaSpace := &KibanaSpace{}
+	err := json.Unmarshal([]byte(`{"id":"test","name":"test","solution":"oblt","projectRouting":{"enabled":true}}`), kibanaSpace)
+	assert.NoError(t, err)
+	assert.Equal(t, "test", kibanaSpace.ID)
+	assert.Equal(t, KibanaSpaceSolutionObservability, kibanaSpace.Solution)
+	assert.Equal(t, json.RawMessage(`{"enabled":true}`), kibanaSpace.Extra["projectRouting"])
+	assert.NotContains(t, kibanaSpace.Extra, "id")
+
+	// Extra attributes are sent back
+	b, err := json.Marshal(kibanaSpace)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"test","name":"test","solution":"oblt","projectRouting":{"enabled":true}}`, string(b))
+
+	// Authorized purposes are never sent
+	kibanaSpace.AuthorizedPurposes = &KibanaSpaceAuthorizedPurposes{Any: true}
+	b, err = json.Marshal(kibanaSpace)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "authorizedPurposes")
+
+	// Known attributes win over extra attributes
+	kibanaSpace.Extra["name"] = json.RawMessage(`"other"`)
+	b, err = json.Marshal(kibanaSpace)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"name":"test"`)
+}
+
+func TestKibanaSpaceValidate(t *testing.T) {
+
+	assert.NoError(t, (&KibanaSpace{ID: "test_1-a", Name: "test", Color: "#aabbcc", Initials: "TE", Solution: KibanaSpaceSolutionClassic}).Validate())
+	assert.Error(t, (&KibanaSpace{Name: "test"}).Validate())
+	assert.Error(t, (&KibanaSpace{ID: "Test", Name: "test"}).Validate())
+	assert.Error(t, (&KibanaSpace{ID: "test space", Name: "test"}).Validate())
+	assert.Error(t, (&KibanaSpace{ID: "test"}).Validate())
+	assert.Error(t, (&KibanaSpace{ID: "test", Name: "test", Color: "#abc"}).Validate())
+	assert.Error(t, (&KibanaSpace{ID: "test", Name: "test", Initials: "ABC"}).Validate())
+	assert.Error(t, (&KibanaSpace{ID: "test", Name: "test", ImageURL: "http://example.com/logo.png"}).Validate())
+	assert.Error(t, (&KibanaSpace{ID: "test", Name: "test", Solution: "unknown"}).Validate())
+}
+
+func TestKibanaSpaceLegacyURLAliases(t *testing.T) {
+
+	var disablePayload []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/saved_objects/_find":
+			assert.Equal(t, "legacy-url-alias", r.URL.Query().Get("type"))
+			assert.Equal(t, "1000", r.URL.Query().Get("per_page"))
+			page := r.URL.Query().Get("page")
+			objects := `[
+				{"id":"test:dashboard:1","attributes":{"targetNamespace":"test","targetType":"dashboard","sourceId":"1","targetId":"new-1"}},
+				{"id":"test:visualization:2","attributes":{"targetNamespace":"test","targetType":"visualization","sourceId":"2","targetId":"new-2"}}
+			]`
+			if page == "2" {
+				objects = `[
+					{"id":"other:dashboard:3","attributes":{"targetNamespace":"other","targetType":"dashboard","sourceId":"3","targetId":"new-3"}},
+					{"id":"test:dashboard:4","attributes":{"targetNamespace":"test","targetType":"dashboard","sourceId":"4","targetId":"new-4","disabled":true}}
+				]`
+			}
+			fmt.Fprintf(w, `{"page":%s,"per_page":1000,"total":1004,"saved_objects":%s}`, page, objects)
+		case "/api/spaces/_disable_legacy_url_aliases":
+			disablePayload, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+
+	// Find all aliases on all pages
+	aliases, err := api.KibanaSpaces.FindLegacyURLAliases("", "")
+	assert.NoError(t, err)
+	assert.Len(t, aliases, 3)
+
+	// Find aliases of dashboards in test space
+	aliases, err = api.KibanaSpaces.FindLegacyURLAliases("test", "dashboard")
+	assert.NoError(t, err)
+	assert.Equal(t, []KibanaSpaceLegacyURLAlias{{TargetSpace: "test", TargetType: "dashboard", SourceID: "1", TargetID: "new-1"}}, aliases)
+
+	// Disable them
+	err = api.KibanaSpaces.DisableLegacyURLAliases(aliases)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"aliases":[{"targetSpace":"test","targetType":"dashboard","sourceId":"1"}]}`, string(disablePayload))
+
+	err = api.KibanaSpaces.DisableLegacyURLAliases([]KibanaSpaceLegacyURLAlias{{TargetSpace: "test"}})
+	assert.Error(t, err)
+}
+
+func TestKibanaSpaceSafeDelete(t *testing.T) {
+
+	var exportPayload []byte
+	excluded := false
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/spaces/space/test":
+			if r.Method == http.MethodDelete {
+				deleted = true
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"test","name":"test"}`))
+		case "/api/kibana/management/saved_objects/_allowed_types":
+			_, _ = w.Write([]byte(`{"types":[{"name":"dashboard","hidden":false},{"name":"cases","hidden":false},{"name":"alert","hidden":true}]}`))
+		case "/s/test/api/saved_objects/_find":
+			assert.Equal(t, []string{"cases"}, r.URL.Query()["type"])
+			assert.Equal(t, "0", r.URL.Query().Get("per_page"))
+			_, _ = w.Write([]byte(`{"page":1,"per_page":0,"total":2,"saved_objects":[]}`))
+		case "/s/test/api/saved_objects/_export":
+			exportPayload, _ = io.ReadAll(r.Body)
+			if excluded {
+				_, _ = w.Write([]byte("{\"type\":\"dashboard\",\"id\":\"1\"}\n{\"exportedCount\":1,\"missingRefCount\":0,\"missingReferences\":[],\"excludedObjectsCount\":1,\"excludedObjects\":[{\"type\":\"alert\",\"id\":\"2\"}]}\n"))
+				return
+			}
+			_, _ = w.Write([]byte("{\"type\":\"dashboard\",\"id\":\"1\"}\n{\"type\":\"alert\",\"id\":\"2\"}\n{\"exportedCount\":2,\"missingRefCount\":0,\"missingReferences\":[],\"excludedObjectsCount\":0,\"excludedObjects\":[]}\n"))
+		case "/api/security/role":
+			_, _ = w.Write([]byte(`[{"name":"reader","kibana":[{"base":["read"],"spaces":["test"]}]}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+	backup := &bytes.Buffer{}
+
+	// Export all exportable types
+	result, err := api.KibanaSpaces.SafeDelete("test", backup, &OptionalSafeDeleteParameters{Confirm: true})
+	assert.NoError(t, err)
+	assert.True(t, result.Deleted)
+	assert.Equal(t, []string{"reader"}, result.Roles)
+	assert.JSONEq(t, `{"type":["dashboard","cases","alert"],"includeReferencesDeep":true,"excludeExportDetails":false}`, string(exportPayload))
+	assert.Equal(t, "{\"type\":\"dashboard\",\"id\":\"1\"}\n{\"type\":\"alert\",\"id\":\"2\"}\n", backup.String())
+	assert.Equal(t, backup.Len(), result.ExportedBytes)
+
+	// Space has objects of types not exported
+	deleted = false
+	backup.Reset()
+	_, err = api.KibanaSpaces.SafeDelete("test", backup, &OptionalSafeDeleteParameters{Confirm: true, ObjectTypes: []string{"dashboard"}})
+	assert.Error(t, err)
+	assert.False(t, deleted)
+	assert.Empty(t, backup.Bytes())
+
+	// Kibana exclude objects from export
+	excluded = true
+	_, err = api.KibanaSpaces.SafeDelete("test", backup, &OptionalSafeDeleteParameters{Confirm: true})
+	assert.Error(t, err)
+	assert.False(t, deleted)
+	assert.Empty(t, backup.Bytes())
+}
+
+func TestKibanaSpacePartialFailure(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/spaces/_copy_saved_objects":
+			_, _ = w.Write([]byte(`{"space1":{"success":true,"successCount":1},"space2":{"success":false,"successCount":0,"errors":[{"type":"dashboard","id":"1","error":{"type":"conflict"}}]}}`))
+		case "/api/spaces/_update_objects_spaces":
+			_, _ = w.Write([]byte(`{"objects":[{"type":"dashboard","id":"1","spaces":["default","space1"]},{"type":"dashboard","id":"2","spaces":[],"error":{"statusCode":404,"error":"Not Found","message":"Saved object [dashboard/2] not found"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := New(resty.New().SetBaseURL(server.URL))
+
+	// Copy failed on one space
+	copyResult, err := api.KibanaSpaces.CopySavedObjects(&KibanaSpaceCopySavedObjectParameter{
+		Spaces:  []string{"space1", "space2"},
+		Objects: []KibanaSpaceObjectParameter{{Type: "dashboard", ID: "1"}},
+	}, "")
+	apiErr := APIError{}
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, 600, apiErr.Code)
+		assert.Equal(t, "Error to process user space space2: object dashboard/1 failed with conflict error", apiErr.Message)
+	}
+	assert.True(t, copyResult["space1"].Success)
+	assert.False(t, copyResult["space2"].Success)
+
+	// Update spaces failed on one object
+	updateResult, err := api.KibanaSpaces.UpdateObjectsSpaces(&KibanaSpaceUpdateObjectsSpacesParameter{
+		Objects:     []KibanaSpaceObjectParameter{{Type: "dashboard", ID: "1"}, {Type: "dashboard", ID: "2"}},
+		SpacesToAdd: []string{"space1"},
+	}, "")
+	apiErr = APIError{}
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, 600, apiErr.Code)
+		assert.Equal(t, "Error to update spaces of object dashboard/2: Saved object [dashboard/2] not found", apiErr.Message)
+	}
+	assert.Len(t, updateResult.Objects, 2)
+}
@@ -45,7 +45,7 @@ func (s *KBAPITestSuite) SetupSuite() {
 	isOnline := false
 	nbTry := 0
 	for isOnline == false {
-		_, err := s.API.KibanaSpaces.List()
+		_, err := s.API.KibanaSpaces.List(nil)
 		if err == nil {
 			isOnline = true
 		} else {
@@ -1,6 +1,7 @@
 package kbapi
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -10,6 +11,19 @@ type APIError struct {
 	Message string
 }
 
+// ConflictObject is the object that raise a conflict
+type ConflictObject struct {
+	Type string
+	ID   string
+}
+
+// ConflictError is the error returned when Kibana reject a request with 409 Conflict
+// It's the case when the version sent is outdated or when object already exist
+type ConflictError struct {
+	APIError
+	Objects []ConflictObject
+}
+
 // Error return error message
 func (e APIError) Error() string {
 	return e.Message
@@ -22,3 +36,17 @@ func NewAPIError(code int, message string, params ...interface{}) APIError {
 		Message: fmt.Sprintf(message, params...),
 	}
 }
+
+// NewConflictError create new conflict error for the list of objects
+func NewConflictError(objects []ConflictObject, message string, params ...interface{}) ConflictError {
+	return ConflictError{
+		APIError: NewAPIError(409, message, params...),
+		Objects:  objects,
+	}
+}
+
+// IsConflictError return true if the error is a ConflictError
+func IsConflictError(err error) bool {
+	conflictError := ConflictError{}
+	return errors.As(err, &conflictError)
+}
@@ -1,10 +1,21 @@
 package kbapi
 
-import "github.com/stretchr/testify/assert"
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func (s *KBAPITestSuite) TestError() {
 
 	err := NewAPIError(404, "test %s error", "plop")
 	assert.Equal(s.T(), 404, err.Code)
 	assert.Equal(s.T(), "test plop error", err.Error())
+
+	conflictErr := NewConflictError([]ConflictObject{{Type: "dashboard", ID: "test"}}, "test %s conflict", "plop")
+	assert.Equal(s.T(), 409, conflictErr.Code)
+	assert.Equal(s.T(), "test plop conflict", conflictErr.Error())
+	assert.True(s.T(), IsConflictError(conflictErr))
+	assert.True(s.T(), IsConflictError(fmt.Errorf("wrapped: %w", conflictErr)))
+	assert.False(s.T(), IsConflictError(err))
 }
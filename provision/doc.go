@@ -0,0 +1,4 @@
+/*
+Package provision provides helpers to provision Kibana resources from declarative templates
+*/
+package provision
@@ -0,0 +1,277 @@
+package provision
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/disaster37/go-kibana-rest/v8/kbapi"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// LogstashPipelinesOptions is the options to reconcile the Logstash pipelines
+type LogstashPipelinesOptions struct {
+	// Prune delete the pipelines on Kibana that not exist on directory
+	Prune bool
+
+	// DryRun only compute the plan, without change on Kibana
+	DryRun bool
+}
+
+// LogstashPipelinesPlan is the pipelines IDs to create, update or delete on Kibana
+type LogstashPipelinesPlan struct {
+	Create    []string
+	Update    []string
+	Delete    []string
+	Unchanged []string
+}
+
+// logstashPipelineIDRegexp is the pipeline ID accepted by Kibana
+var logstashPipelineIDRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// logstashPipelineMetadata is the content of YAML metadata file
+type logstashPipelineMetadata struct {
+	Description string                 `yaml:"description"`
+	Username    string                 `yaml:"username"`
+	Settings    map[string]interface{} `yaml:"settings"`
+}
+
+// HasChanges return true if the plan change something on Kibana
+func (p *LogstashPipelinesPlan) HasChanges() bool {
+	return len(p.Create)+len(p.Update)+len(p.Delete) > 0
+}
+
+// String return the plan as human readable text, one pipeline by line
+func (p *LogstashPipelinesPlan) String() string {
+	if !p.HasChanges() {
+		return "Logstash pipelines: no changes"
+	}
+
+	lines := []string{fmt.Sprintf("Logstash pipelines: %d to create, %d to update, %d to delete", len(p.Create), len(p.Update), len(p.Delete))}
+	for _, id := range p.Create {
+		lines = append(lines, fmt.Sprintf("+ %s", id))
+	}
+	for _, id := range p.Update {
+		lines = append(lines, fmt.Sprintf("~ %s", id))
+	}
+	for _, id := range p.Delete {
+		lines = append(lines, fmt.Sprintf("- %s", id))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ReadLogstashPipelines read the pipelines from directory.
+// Each <id>.conf file is a pipeline, with optional <id>.yml or <id>.yaml metadata file that contain
+// description, username and settings (pipeline.workers, queue.type, ...).
+// The IDs, pipelines and settings are validated. Settings not handled by kbapi are sent as is.
+func ReadLogstashPipelines(dir string) (kbapi.LogstashPipelines, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	logstashPipelines := make(kbapi.LogstashPipelines, 0, len(files))
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".conf")
+		if !logstashPipelineIDRegexp.MatchString(id) {
+			return nil, fmt.Errorf("%s: pipeline ID %s must begin with letter or underscore, and contain only letters, numbers, underscores and dashes", file, id)
+		}
+		pipeline, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = kbapi.ParseLogstashPipelineConfig(string(pipeline)); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		logstashPipeline := kbapi.LogstashPipeline{
+			ID:       id,
+			Pipeline: string(pipeline),
+		}
+		if err = readLogstashPipelineMetadata(dir, &logstashPipeline); err != nil {
+			return nil, err
+		}
+		logstashPipelines = append(logstashPipelines, logstashPipeline)
+	}
+
+	return logstashPipelines, nil
+}
+
+// LogstashPipelines reconcile the pipelines read from directory into Kibana centralized management.
+// New pipelines are created, pipelines with different pipeline, description or settings are updated,
+// and with Prune, pipelines not on directory are deleted. It return the plan, applied if not DryRun.
+func LogstashPipelines(api *kbapi.API, dir string, options *LogstashPipelinesOptions) (*LogstashPipelinesPlan, error) {
+
+	if api == nil {
+		return nil, kbapi.NewAPIError(600, "You must provide the Kibana API")
+	}
+	if options == nil {
+		options = &LogstashPipelinesOptions{}
+	}
+
+	desiredPipelines, err := ReadLogstashPipelines(dir)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Read %d logstash pipelines from %s", len(desiredPipelines), dir)
+
+	// The list not return the pipeline, so we need to get them
+	currentPipelines, err := api.KibanaLogstashPipeline.List()
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]bool, len(currentPipelines))
+	for _, logstashPipeline := range currentPipelines {
+		current[logstashPipeline.ID] = true
+	}
+
+	plan := &LogstashPipelinesPlan{}
+	desired := make(map[string]bool, len(desiredPipelines))
+	for i := range desiredPipelines {
+		desiredPipeline := &desiredPipelines[i]
+		desired[desiredPipeline.ID] = true
+		if !current[desiredPipeline.ID] {
+			plan.Create = append(plan.Create, desiredPipeline.ID)
+			continue
+		}
+
+		currentPipeline, err := api.KibanaLogstashPipeline.Get(desiredPipeline.ID)
+		if err != nil {
+			return nil, err
+		}
+		if currentPipeline == nil {
+			plan.Create = append(plan.Create, desiredPipeline.ID)
+		} else if logstashPipelineChanged(desiredPipeline, currentPipeline) {
+			plan.Update = append(plan.Update, desiredPipeline.ID)
+		} else {
+			plan.Unchanged = append(plan.Unchanged, desiredPipeline.ID)
+		}
+	}
+	if options.Prune {
+		for _, logstashPipeline := range currentPipelines {
+			if !desired[logstashPipeline.ID] {
+				plan.Delete = append(plan.Delete, logstashPipeline.ID)
+			}
+		}
+		sort.Strings(plan.Delete)
+	}
+	log.Debug("Plan: ", plan)
+
+	if options.DryRun {
+		return plan, nil
+	}
+
+	for i := range desiredPipelines {
+		desiredPipeline := &desiredPipelines[i]
+		if !stringInSlice(desiredPipeline.ID, plan.Create) && !stringInSlice(desiredPipeline.ID, plan.Update) {
+			continue
+		}
+		log.Debugf("Create or update logstash pipeline %s", desiredPipeline.ID)
+		if _, _, err = api.KibanaLogstashPipeline.CreateOrUpdate(desiredPipeline, &kbapi.OptionalCreateOrUpdatePipelineParameters{SkipFetch: true}); err != nil {
+			return plan, err
+		}
+	}
+	for _, id := range plan.Delete {
+		log.Debugf("Delete logstash pipeline %s", id)
+		if err = api.KibanaLogstashPipeline.Delete(id); err != nil {
+			return plan, err
+		}
+	}
+
+	return plan, nil
+}
+
+// readLogstashPipelineMetadata read the YAML metadata of pipeline if exist
+func readLogstashPipelineMetadata(dir string, logstashPipeline *kbapi.LogstashPipeline) error {
+	for _, extension := range []string{".yml", ".yaml"} {
+		file := filepath.Join(dir, logstashPipeline.ID+extension)
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// Misspelled keys must fail, else they are silently ignored
+		metadata := &logstashPipelineMetadata{}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(metadata); err != nil && err != io.EOF {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		logstashPipeline.Description = metadata.Description
+		logstashPipeline.Username = metadata.Username
+		if len(metadata.Settings) > 0 {
+			// Use JSON to check the settings type
+			settingsData, err := json.Marshal(metadata.Settings)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			settings := &kbapi.LogstashPipelineSettings{}
+			if err = json.Unmarshal(settingsData, settings); err != nil {
+				return fmt.Errorf("%s: invalid settings: %w", file, err)
+			}
+			if err = settings.Validate(); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			logstashPipeline.Settings = settings
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// logstashPipelineChanged return true if the pipeline, description or settings are different
+// The settings are compared as JSON, to compare the extra settings whatever their format
+func logstashPipelineChanged(desired *kbapi.LogstashPipeline, current *kbapi.LogstashPipeline) bool {
+	if desired.Pipeline != current.Pipeline || desired.Description != current.Description {
+		return true
+	}
+	desiredSettings, err := logstashPipelineSettingsMap(desired.Settings)
+	if err != nil {
+		return true
+	}
+	currentSettings, err := logstashPipelineSettingsMap(current.Settings)
+	if err != nil {
+		return true
+	}
+	return !reflect.DeepEqual(desiredSettings, currentSettings)
+}
+
+// logstashPipelineSettingsMap return the settings as generic JSON map
+func logstashPipelineSettingsMap(settings *kbapi.LogstashPipelineSettings) (map[string]interface{}, error) {
+	settingsMap := make(map[string]interface{})
+	if settings == nil {
+		return settingsMap, nil
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &settingsMap); err != nil {
+		return nil, err
+	}
+	return settingsMap, nil
+}
+
+// stringInSlice return true if value is in list
+func stringInSlice(value string, list []string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package provision
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/disaster37/go-kibana-rest/v8/kbapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *ProvisionTestSuite) writeFile(dir string, name string, content string) {
+	err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600)
+	assert.NoError(s.T(), err)
+}
+
+func (s *ProvisionTestSuite) TestLogstashPipelines() {
+	dir := s.T().TempDir()
+	s.writeFile(dir, "main.conf", "input { beats { port => 5044 } } output { stdout {} }")
+	s.writeFile(dir, "main.yml", "description: Main pipeline\nsettings:\n  pipeline.workers: 2\n  queue.type: persisted\n")
+	s.writeFile(dir, "debug.conf", "input { stdin {} } output { stdout {} }")
+	s.kibana.pipelines["legacy"] = []byte(`{"id":"legacy","pipeline":"input { stdin {} } output { stdout {} }"}`)
+
+	// Dry run not change Kibana
+	plan, err := LogstashPipelines(s.api, dir, &LogstashPipelinesOptions{Prune: true, DryRun: true})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"debug", "main"}, plan.Create)
+	assert.Equal(s.T(), []string{"legacy"}, plan.Delete)
+	assert.Equal(s.T(), "Logstash pipelines: 2 to create, 0 to update, 1 to delete\n+ debug\n+ main\n- legacy", plan.String())
+	assert.Len(s.T(), s.kibana.pipelines, 1)
+
+	// Apply without prune
+	plan, err = LogstashPipelines(s.api, dir, nil)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"debug", "main"}, plan.Create)
+	assert.Empty(s.T(), plan.Delete)
+	main, err := s.api.KibanaLogstashPipeline.Get("main")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "Main pipeline", main.Description)
+	assert.Equal(s.T(), 2, *main.Settings.PipelineWorkers)
+	assert.Equal(s.T(), kbapi.LogstashPipelineQueuePersisted, main.Settings.QueueType)
+
+	// Nothing change when apply again
+	plan, err = LogstashPipelines(s.api, dir, nil)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), plan.HasChanges())
+	assert.Equal(s.T(), []string{"debug", "main"}, plan.Unchanged)
+
+	// Update changed settings and prune
+	s.writeFile(dir, "main.yml", "description: Main pipeline\nsettings:\n  pipeline.workers: 4\n  queue.type: persisted\n")
+	plan, err = LogstashPipelines(s.api, dir, &LogstashPipelinesOptions{Prune: true})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"main"}, plan.Update)
+	assert.Equal(s.T(), []string{"legacy"}, plan.Delete)
+	assert.NotContains(s.T(), s.kibana.pipelines, "legacy")
+
+	// Settings not handled by kbapi are sent and compared
+	s.writeFile(dir, "main.yml", "description: Main pipeline\nsettings:\n  pipeline.workers: 4\n  queue.type: persisted\n  queue.page_capacity: 64mb\n")
+	plan, err = LogstashPipelines(s.api, dir, nil)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"main"}, plan.Update)
+	main, err = s.api.KibanaLogstashPipeline.Get("main")
+	assert.NoError(s.T(), err)
+	assert.JSONEq(s.T(), `"64mb"`, string(main.Settings.Extra["queue.page_capacity"]))
+	plan, err = LogstashPipelines(s.api, dir, nil)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), plan.HasChanges())
+}
+
+func (s *ProvisionTestSuite) TestLogstashPipelinesInvalid() {
+
+	// Invalid pipeline
+	dir := s.T().TempDir()
+	s.writeFile(dir, "main.conf", "input { beats { port => } }")
+	_, err := LogstashPipelines(s.api, dir, nil)
+	assert.Error(s.T(), err)
+	assert.Empty(s.T(), s.kibana.pipelines)
+
+	// Invalid settings
+	dir = s.T().TempDir()
+	s.writeFile(dir, "main.conf", "input { stdin {} } output { stdout {} }")
+	s.writeFile(dir, "main.yaml", "settings:\n  pipeline.workers: two\n")
+	_, err = LogstashPipelines(s.api, dir, nil)
+	assert.Error(s.T(), err)
+	s.writeFile(dir, "main.yaml", "settings:\n  queue.max_bytes: 1024\n")
+	_, err = LogstashPipelines(s.api, dir, nil)
+	assert.Error(s.T(), err)
+
+	// Misspelled keys
+	s.writeFile(dir, "main.yaml", "descripton: Main pipeline\n")
+	_, err = LogstashPipelines(s.api, dir, nil)
+	assert.Error(s.T(), err)
+	assert.Empty(s.T(), s.kibana.pipelines)
+
+	// Invalid ID
+	dir = s.T().TempDir()
+	s.writeFile(dir, "1-main.conf", "input { stdin {} } output { stdout {} }")
+	_, err = LogstashPipelines(s.api, dir, nil)
+	assert.Error(s.T(), err)
+	assert.NoError(s.T(), os.Remove(filepath.Join(dir, "1-main.conf")))
+	s.writeFile(dir, "main pipeline.conf", "input { stdin {} } output { stdout {} }")
+	_, err = LogstashPipelines(s.api, dir, nil)
+	assert.Error(s.T(), err)
+	assert.Empty(s.T(), s.kibana.pipelines)
+
+	_, err = LogstashPipelines(nil, dir, nil)
+	assert.Error(s.T(), err)
+}
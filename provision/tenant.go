@@ -0,0 +1,277 @@
+package provision
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/disaster37/go-kibana-rest/v8/kbapi"
+	log "github.com/sirupsen/logrus"
+)
+
+// TenantTemplate is the declarative definition of a tenant
+type TenantTemplate struct {
+	// Space is the space dedicated to the tenant
+	Space kbapi.KibanaSpace
+
+	// SourceSpace is the space where the standard objects are copied from
+	SourceSpace string
+
+	// Objects is the standard objects (dashboards, data views, ...) copied from SourceSpace with their references
+	Objects []kbapi.KibanaSpaceObjectParameter
+
+	// Imports is the list of ndjson exports imported in the space
+	Imports [][]byte
+
+	// Roles is the roles scoped to the space
+	Roles []RoleTemplate
+}
+
+// RoleTemplate is the definition of a role scoped to the tenant space
+type RoleTemplate struct {
+	Name          string
+	Base          []string
+	Feature       map[string][]string
+	Elasticsearch *kbapi.KibanaRoleElasticsearch
+	Metadata      map[string]interface{}
+}
+
+// TenantResult is the resources created or updated when provisioning the tenant
+type TenantResult struct {
+	SpaceCreated    bool
+	SpaceUpdated    bool
+	CopiedObjects   int
+	ImportedObjects int
+	RolesCreated    []string
+	RolesUpdated    []string
+}
+
+// ReadOnlyAndEditorRoles return the standard read only and editor roles for a tenant.
+// Roles are named <prefix>_read and <prefix>_editor
+func ReadOnlyAndEditorRoles(prefix string) []RoleTemplate {
+	return []RoleTemplate{
+		{
+			Name: fmt.Sprintf("%s_read", prefix),
+			Base: []string{"read"},
+		},
+		{
+			Name: fmt.Sprintf("%s_editor", prefix),
+			Base: []string{"all"},
+		},
+	}
+}
+
+// Role return the Kibana role that grant the privileges of the template on the space
+func (r *RoleTemplate) Role(spaceID string) *kbapi.KibanaRole {
+	return &kbapi.KibanaRole{
+		Name:          r.Name,
+		Metadata:      r.Metadata,
+		Elasticsearch: r.Elasticsearch,
+		Kibana: []kbapi.KibanaRoleKibana{
+			{
+				Base:    r.Base,
+				Feature: r.Feature,
+				Spaces:  []string{spaceID},
+			},
+		},
+	}
+}
+
+// Validate permit to check the template before provisioning it
+func (t *TenantTemplate) Validate() error {
+	if err := t.Space.Validate(); err != nil {
+		return err
+	}
+	if len(t.Objects) > 0 && t.SourceSpace == t.Space.ID {
+		return kbapi.NewAPIError(600, "Source space must be different than tenant space %s", t.Space.ID)
+	}
+	for _, role := range t.Roles {
+		if role.Name == "" {
+			return kbapi.NewAPIError(600, "You must provide the role name")
+		}
+		if len(role.Base) == 0 && len(role.Feature) == 0 {
+			return kbapi.NewAPIError(600, "Role %s must grant base or feature privileges", role.Name)
+		}
+	}
+
+	return nil
+}
+
+// Tenant create or update the tenant space, copy and import the standard objects and create or update the roles.
+// It can be run many times with the same template. When a step failed, the space and roles created
+// by this call are deleted and the space and roles updated are restored.
+// Objects copied or imported in an already existing space are not rolled back.
+func Tenant(api *kbapi.API, template *TenantTemplate) (*TenantResult, error) {
+
+	if api == nil {
+		return nil, kbapi.NewAPIError(600, "You must provide the Kibana API")
+	}
+	if template == nil {
+		return nil, kbapi.NewAPIError(600, "You must provide the tenant template")
+	}
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+	log.Debug("Template: ", template)
+
+	p := &tenantProvisioner{
+		api:           api,
+		template:      template,
+		result:        &TenantResult{},
+		previousRoles: map[string]*kbapi.KibanaRole{},
+	}
+	if err := p.run(); err != nil {
+		if rollbackErr := p.rollback(); rollbackErr != nil {
+			return p.result, kbapi.NewAPIError(600, "Error when provisioning tenant %s: %s\nRollback failed: %s", template.Space.ID, err, rollbackErr)
+		}
+		return p.result, err
+	}
+
+	return p.result, nil
+}
+
+// tenantProvisioner keep the state needed to rollback the provisioning
+type tenantProvisioner struct {
+	api           *kbapi.API
+	template      *TenantTemplate
+	result        *TenantResult
+	previousSpace *kbapi.KibanaSpace
+	previousRoles map[string]*kbapi.KibanaRole
+}
+
+// run apply the template step by step
+func (p *tenantProvisioner) run() error {
+	if err := p.provisionSpace(); err != nil {
+		return err
+	}
+	if err := p.provisionObjects(); err != nil {
+		return err
+	}
+	return p.provisionRoles()
+}
+
+// provisionSpace create the space or update it if already exist
+func (p *tenantProvisioner) provisionSpace() error {
+	space := p.template.Space
+
+	currentSpace, err := p.api.KibanaSpaces.Get(space.ID)
+	if err != nil {
+		return err
+	}
+	if currentSpace == nil {
+		log.Debugf("Create space %s", space.ID)
+		if _, err = p.api.KibanaSpaces.Create(&space); err != nil {
+			return err
+		}
+		p.result.SpaceCreated = true
+		return nil
+	}
+
+	// Keep the attributes not handled by the template
+	if space.Extra == nil {
+		space.Extra = currentSpace.Extra
+	}
+	log.Debugf("Update space %s", space.ID)
+	if _, err = p.api.KibanaSpaces.Update(&space); err != nil {
+		return err
+	}
+	p.previousSpace = currentSpace
+	p.result.SpaceUpdated = true
+
+	return nil
+}
+
+// provisionObjects copy and import the standard objects in the space
+func (p *tenantProvisioner) provisionObjects() error {
+	spaceID := p.template.Space.ID
+
+	if len(p.template.Objects) > 0 {
+		log.Debugf("Copy %d objects from space %s to space %s", len(p.template.Objects), p.template.SourceSpace, spaceID)
+		copyResult, err := p.api.KibanaSpaces.CopySavedObjects(&kbapi.KibanaSpaceCopySavedObjectParameter{
+			Spaces:            []string{spaceID},
+			IncludeReferences: true,
+			Overwrite:         true,
+			Objects:           p.template.Objects,
+		}, p.template.SourceSpace)
+		if err != nil {
+			return err
+		}
+		p.result.CopiedObjects += copyResult[spaceID].SuccessCount
+	}
+
+	for _, data := range p.template.Imports {
+		log.Debugf("Import objects in space %s", spaceID)
+		importResult, err := p.api.KibanaSavedObject.Import(data, true, spaceID)
+		if err != nil {
+			return err
+		}
+		if success, _ := importResult["success"].(bool); !success {
+			return kbapi.NewAPIError(600, "Error when import objects in space %s: %v", spaceID, importResult["errors"])
+		}
+		if successCount, ok := importResult["successCount"].(float64); ok {
+			p.result.ImportedObjects += int(successCount)
+		}
+	}
+
+	return nil
+}
+
+// provisionRoles create or update the roles scoped to the space
+func (p *tenantProvisioner) provisionRoles() error {
+	for _, roleTemplate := range p.template.Roles {
+		currentRole, err := p.api.KibanaRoleManagement.Get(roleTemplate.Name)
+		if err != nil {
+			return err
+		}
+
+		log.Debugf("Create or update role %s", roleTemplate.Name)
+		if _, err = p.api.KibanaRoleManagement.CreateOrUpdate(roleTemplate.Role(p.template.Space.ID)); err != nil {
+			return err
+		}
+		if currentRole == nil {
+			p.result.RolesCreated = append(p.result.RolesCreated, roleTemplate.Name)
+		} else {
+			p.previousRoles[roleTemplate.Name] = currentRole
+			p.result.RolesUpdated = append(p.result.RolesUpdated, roleTemplate.Name)
+		}
+	}
+
+	return nil
+}
+
+// rollback delete the resources created and restore the resources updated, in reverse order
+func (p *tenantProvisioner) rollback() error {
+	var errors []string
+
+	for i := len(p.result.RolesUpdated) - 1; i >= 0; i-- {
+		name := p.result.RolesUpdated[i]
+		log.Debugf("Rollback: restore role %s", name)
+		if _, err := p.api.KibanaRoleManagement.CreateOrUpdate(p.previousRoles[name]); err != nil {
+			errors = append(errors, fmt.Sprintf("Error when restore role %s: %s", name, err))
+		}
+	}
+	for i := len(p.result.RolesCreated) - 1; i >= 0; i-- {
+		name := p.result.RolesCreated[i]
+		log.Debugf("Rollback: delete role %s", name)
+		if err := p.api.KibanaRoleManagement.Delete(name); err != nil {
+			errors = append(errors, fmt.Sprintf("Error when delete role %s: %s", name, err))
+		}
+	}
+
+	if p.result.SpaceCreated {
+		log.Debugf("Rollback: delete space %s", p.template.Space.ID)
+		if err := p.api.KibanaSpaces.Delete(p.template.Space.ID); err != nil {
+			errors = append(errors, fmt.Sprintf("Error when delete space %s: %s", p.template.Space.ID, err))
+		}
+	} else if p.previousSpace != nil {
+		log.Debugf("Rollback: restore space %s", p.template.Space.ID)
+		if _, err := p.api.KibanaSpaces.Update(p.previousSpace); err != nil {
+			errors = append(errors, fmt.Sprintf("Error when restore space %s: %s", p.template.Space.ID, err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return kbapi.NewAPIError(600, strings.Join(errors, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package provision
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/disaster37/go-kibana-rest/v8/kbapi"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeKibana is a minimal in memory Kibana that handle spaces, copy, import and roles
+type fakeKibana struct {
+	sync.Mutex
+	spaces    map[string]json.RawMessage
+	roles     map[string]json.RawMessage
+	pipelines map[string]json.RawMessage
+	failRoles map[string]bool
+	copied    int
+	imported  int
+}
+
+func newFakeKibana() *fakeKibana {
+	return &fakeKibana{
+		spaces:    map[string]json.RawMessage{},
+		roles:     map[string]json.RawMessage{},
+		pipelines: map[string]json.RawMessage{},
+		failRoles: map[string]bool{},
+	}
+}
+
+func (f *fakeKibana) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	path := r.URL.Path
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case path == "/api/spaces/space" && r.Method == http.MethodPost:
+		space := &kbapi.KibanaSpace{}
+		_ = json.Unmarshal(body, space)
+		f.spaces[space.ID] = body
+		_, _ = w.Write(body)
+	case strings.HasPrefix(path, "/api/spaces/space/"):
+		id := strings.TrimPrefix(path, "/api/spaces/space/")
+		f.handleObject(w, r, f.spaces, id, body)
+	case path == "/api/spaces/_copy_saved_objects":
+		parameter := &kbapi.KibanaSpaceCopySavedObjectParameter{}
+		_ = json.Unmarshal(body, parameter)
+		result := kbapi.KibanaSpaceCopySavedObjectsResult{}
+		for _, space := range parameter.Spaces {
+			result[space] = kbapi.KibanaSpaceCopySavedObjectsSpaceResult{Success: true, SuccessCount: len(parameter.Objects)}
+			f.copied += len(parameter.Objects)
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	case strings.HasSuffix(path, "/api/saved_objects/_import"):
+		f.imported++
+		_, _ = w.Write([]byte(`{"success":true,"successCount":1}`))
+	case strings.HasPrefix(path, "/api/security/role/"):
+		name := strings.TrimPrefix(path, "/api/security/role/")
+		if r.Method == http.MethodPut && f.failRoles[name] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPut {
+			role := map[string]interface{}{}
+			_ = json.Unmarshal(body, &role)
+			role["name"] = name
+			body, _ = json.Marshal(role)
+		}
+		f.handleObject(w, r, f.roles, name, body)
+	case path == "/api/logstash/pipelines":
+		list := kbapi.LogstashPipelinesList{Pipelines: kbapi.LogstashPipelines{}}
+		for id := range f.pipelines {
+			list.Pipelines = append(list.Pipelines, kbapi.LogstashPipeline{ID: id})
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case strings.HasPrefix(path, "/api/logstash/pipeline/"):
+		id := strings.TrimPrefix(path, "/api/logstash/pipeline/")
+		if r.Method == http.MethodPut {
+			pipeline := map[string]interface{}{}
+			_ = json.Unmarshal(body, &pipeline)
+			pipeline["id"] = id
+			body, _ = json.Marshal(pipeline)
+		}
+		f.handleObject(w, r, f.pipelines, id, body)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeKibana) handleObject(w http.ResponseWriter, r *http.Request, objects map[string]json.RawMessage, id string, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		object, ok := objects[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(object)
+	case http.MethodPut:
+		objects[id] = body
+		_, _ = w.Write(body)
+	case http.MethodDelete:
+		delete(objects, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type ProvisionTestSuite struct {
+	suite.Suite
+	kibana *fakeKibana
+	server *httptest.Server
+	api    *kbapi.API
+}
+
+func (s *ProvisionTestSuite) SetupTest() {
+	s.kibana = newFakeKibana()
+	s.server = httptest.NewServer(s.kibana)
+	s.api = kbapi.New(resty.New().SetBaseURL(s.server.URL).SetHeader("Content-Type", "application/json"))
+}
+
+func (s *ProvisionTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func TestProvisionTestSuite(t *testing.T) {
+	suite.Run(t, new(ProvisionTestSuite))
+}
+
+func (s *ProvisionTestSuite) template() *TenantTemplate {
+	return &TenantTemplate{
+		Space: kbapi.KibanaSpace{
+			ID:   "tenant1",
+			Name: "Tenant 1",
+		},
+		SourceSpace: "default",
+		Objects: []kbapi.KibanaSpaceObjectParameter{
+			{
+				Type: "dashboard",
+				ID:   "standard",
+			},
+		},
+		Imports: [][]byte{[]byte(`{"type":"index-pattern","id":"logs","attributes":{"title":"logs-*"}}`)},
+		Roles:   ReadOnlyAndEditorRoles("tenant1"),
+	}
+}
+
+func (s *ProvisionTestSuite) TestTenant() {
+
+	// Provision new tenant
+	result, err := Tenant(s.api, s.template())
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), result.SpaceCreated)
+	assert.Equal(s.T(), 1, result.CopiedObjects)
+	assert.Equal(s.T(), 1, result.ImportedObjects)
+	assert.Equal(s.T(), []string{"tenant1_read", "tenant1_editor"}, result.RolesCreated)
+	assert.Contains(s.T(), s.kibana.spaces, "tenant1")
+	assert.Contains(s.T(), s.kibana.roles, "tenant1_read")
+	role, err := s.api.KibanaRoleManagement.Get("tenant1_editor")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"tenant1"}, role.Kibana[0].Spaces)
+	assert.Equal(s.T(), []string{"all"}, role.Kibana[0].Base)
+
+	// Provision the same tenant again
+	result, err = Tenant(s.api, s.template())
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), result.SpaceCreated)
+	assert.True(s.T(), result.SpaceUpdated)
+	assert.Empty(s.T(), result.RolesCreated)
+	assert.Equal(s.T(), []string{"tenant1_read", "tenant1_editor"}, result.RolesUpdated)
+}
+
+func (s *ProvisionTestSuite) TestTenantRollback() {
+
+	// The editor role failed, so the space and read role must be deleted
+	s.kibana.failRoles["tenant1_editor"] = true
+	result, err := Tenant(s.api, s.template())
+	assert.Error(s.T(), err)
+	assert.True(s.T(), result.SpaceCreated)
+	assert.Equal(s.T(), []string{"tenant1_read"}, result.RolesCreated)
+	assert.NotContains(s.T(), s.kibana.spaces, "tenant1")
+	assert.NotContains(s.T(), s.kibana.roles, "tenant1_read")
+
+	// The existing space and roles must be restored
+	s.kibana.failRoles = map[string]bool{}
+	_, err = Tenant(s.api, s.template())
+	assert.NoError(s.T(), err)
+	template := s.template()
+	template.Space.Name = "New name"
+	template.Roles[0].Base = []string{"all"}
+	s.kibana.failRoles["tenant1_editor"] = true
+	_, err = Tenant(s.api, template)
+	assert.Error(s.T(), err)
+	space, err := s.api.KibanaSpaces.Get("tenant1")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "Tenant 1", space.Name)
+	role, err := s.api.KibanaRoleManagement.Get("tenant1_read")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"read"}, role.Kibana[0].Base)
+}
+
+func (s *ProvisionTestSuite) TestTenantValidate() {
+
+	_, err := Tenant(nil, s.template())
+	assert.Error(s.T(), err)
+
+	_, err = Tenant(s.api, nil)
+	assert.Error(s.T(), err)
+
+	template := s.template()
+	template.Space.ID = "Bad ID"
+	_, err = Tenant(s.api, template)
+	assert.Error(s.T(), err)
+
+	template = s.template()
+	template.SourceSpace = "tenant1"
+	_, err = Tenant(s.api, template)
+	assert.Error(s.T(), err)
+
+	template = s.template()
+	template.Roles = append(template.Roles, RoleTemplate{Name: "empty"})
+	_, err = Tenant(s.api, template)
+	assert.Error(s.T(), err)
+}
@@ -0,0 +1,382 @@
+/*
+Package rison encode and decode Rison, the compact JSON used by Kibana on URL state (_g and _a).
+It also handle O-Rison (object without parenthesis) and A-Rison (array without !( and )).
+
+See https://github.com/Nanonid/rison
+*/
+package rison
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	notIDChars = " '!:(),*@$"
+	notIDStart = "-0123456789"
+)
+
+// SyntaxError is the error when decode invalid Rison
+type SyntaxError struct {
+	Offset  int
+	Message string
+}
+
+// Error return the error message with the position
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("Rison syntax error at offset %d: %s", e.Offset, e.Message)
+}
+
+// Encode return the Rison of value. The value is converted like encoding/json do.
+func Encode(value any) (string, error) {
+	normalized, err := normalize(value)
+	if err != nil {
+		return "", err
+	}
+	buffer := &strings.Builder{}
+	if err = encodeValue(buffer, normalized); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// EncodeObject return the O-Rison of value, that must be object
+func EncodeObject(value any) (string, error) {
+	s, err := Encode(value)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(s, "(") {
+		return "", fmt.Errorf("O-Rison value must be object, got %s", s)
+	}
+	return s[1 : len(s)-1], nil
+}
+
+// EncodeArray return the A-Rison of value, that must be array
+func EncodeArray(value any) (string, error) {
+	s, err := Encode(value)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(s, "!(") {
+		return "", fmt.Errorf("A-Rison value must be array, got %s", s)
+	}
+	return s[2 : len(s)-1], nil
+}
+
+// Decode return the value of Rison.
+// Objects are map[string]any, arrays are []any and numbers are float64, like encoding/json do.
+func Decode(s string) (any, error) {
+	p := &parser{input: s}
+	value, err := p.readValue()
+	if err != nil {
+		return nil, err
+	}
+	if p.offset < len(p.input) {
+		return nil, p.errorf("unexpected %q after value", p.input[p.offset])
+	}
+	return value, nil
+}
+
+// DecodeObject return the object of O-Rison
+func DecodeObject(s string) (map[string]any, error) {
+	value, err := Decode("(" + s + ")")
+	if err != nil {
+		return nil, err
+	}
+	return value.(map[string]any), nil
+}
+
+// DecodeArray return the array of A-Rison
+func DecodeArray(s string) ([]any, error) {
+	value, err := Decode("!(" + s + ")")
+	if err != nil {
+		return nil, err
+	}
+	return value.([]any), nil
+}
+
+// Unmarshal decode the Rison into value, like json.Unmarshal
+func Unmarshal(s string, value any) error {
+	decoded, err := Decode(s)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(decoded)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, value)
+}
+
+// QueryEscape escape the Rison to put it on URL, keeping the characters used by Rison readable like Kibana do
+func QueryEscape(s string) string {
+	escaped := url.QueryEscape(s)
+	return strings.NewReplacer(
+		"%21", "!",
+		"%27", "'",
+		"%28", "(",
+		"%29", ")",
+		"%2A", "*",
+		"%2C", ",",
+		"%3A", ":",
+		"%40", "@",
+		"%24", "$",
+		"%2F", "/",
+	).Replace(escaped)
+}
+
+// normalize convert the value to the generic types of encoding/json, keeping the numbers as json.Number
+func normalize(value any) (any, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var normalized any
+	if err = decoder.Decode(&normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func encodeValue(buffer *strings.Builder, value any) error {
+	switch v := value.(type) {
+	case nil:
+		buffer.WriteString("!n")
+	case bool:
+		if v {
+			buffer.WriteString("!t")
+		} else {
+			buffer.WriteString("!f")
+		}
+	case json.Number:
+		buffer.WriteString(strings.ReplaceAll(strings.ToLower(v.String()), "+", ""))
+	case string:
+		encodeString(buffer, v)
+	case []any:
+		buffer.WriteString("!(")
+		for i, item := range v {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := encodeValue(buffer, item); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(')')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buffer.WriteByte('(')
+		for i, key := range keys {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			encodeString(buffer, key)
+			buffer.WriteByte(':')
+			if err := encodeValue(buffer, v[key]); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(')')
+	default:
+		return fmt.Errorf("unsupported type %T", value)
+	}
+	return nil
+}
+
+// encodeString write the string as id when possible, else quoted
+func encodeString(buffer *strings.Builder, s string) {
+	if isID(s) {
+		buffer.WriteString(s)
+		return
+	}
+	buffer.WriteByte('\'')
+	for _, c := range s {
+		if c == '!' || c == '\'' {
+			buffer.WriteByte('!')
+		}
+		buffer.WriteRune(c)
+	}
+	buffer.WriteByte('\'')
+}
+
+// isID return true if the string can be written without quote
+func isID(s string) bool {
+	if s == "" || strings.ContainsRune(notIDStart, rune(s[0])) {
+		return false
+	}
+	return !strings.ContainsAny(s, notIDChars)
+}
+
+// parser is the Rison decoder
+type parser struct {
+	input  string
+	offset int
+}
+
+func (p *parser) readValue() (any, error) {
+	if p.offset >= len(p.input) {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	switch c := p.input[p.offset]; {
+	case c == '!':
+		p.offset++
+		if p.offset >= len(p.input) {
+			return nil, p.errorf("unexpected end of input after !")
+		}
+		c = p.input[p.offset]
+		p.offset++
+		switch c {
+		case 't':
+			return true, nil
+		case 'f':
+			return false, nil
+		case 'n':
+			return nil, nil
+		case '(':
+			return p.readArray()
+		default:
+			p.offset--
+			return nil, p.errorf("unknown literal !%c", c)
+		}
+	case c == '(':
+		p.offset++
+		return p.readObject()
+	case c == '\'':
+		return p.readString()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.readNumber()
+	default:
+		start := p.offset
+		for p.offset < len(p.input) && !strings.ContainsRune(notIDChars, rune(p.input[p.offset])) {
+			p.offset++
+		}
+		if p.offset == start {
+			return nil, p.errorf("unexpected %q", c)
+		}
+		return p.input[start:p.offset], nil
+	}
+}
+
+func (p *parser) readArray() ([]any, error) {
+	array := make([]any, 0)
+	if p.consume(')') {
+		return array, nil
+	}
+	for {
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+		if p.consume(',') {
+			continue
+		}
+		if p.consume(')') {
+			return array, nil
+		}
+		return nil, p.errorf("expected , or ) in array")
+	}
+}
+
+func (p *parser) readObject() (map[string]any, error) {
+	object := make(map[string]any)
+	if p.consume(')') {
+		return object, nil
+	}
+	for {
+		start := p.offset
+		key, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			p.offset = start
+			return nil, p.errorf("object key must be string")
+		}
+		if !p.consume(':') {
+			return nil, p.errorf("expected : after key %s", keyString)
+		}
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		object[keyString] = value
+		if p.consume(',') {
+			continue
+		}
+		if p.consume(')') {
+			return object, nil
+		}
+		return nil, p.errorf("expected , or ) in object")
+	}
+}
+
+func (p *parser) readString() (string, error) {
+	start := p.offset
+	p.offset++
+	buffer := &strings.Builder{}
+	for p.offset < len(p.input) {
+		c := p.input[p.offset]
+		p.offset++
+		switch c {
+		case '\'':
+			return buffer.String(), nil
+		case '!':
+			if p.offset >= len(p.input) {
+				break
+			}
+			escaped := p.input[p.offset]
+			if escaped != '!' && escaped != '\'' {
+				return "", p.errorf("invalid string escape !%c", escaped)
+			}
+			buffer.WriteByte(escaped)
+			p.offset++
+		default:
+			buffer.WriteByte(c)
+		}
+	}
+	p.offset = start
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) readNumber() (float64, error) {
+	start := p.offset
+	for p.offset < len(p.input) && strings.ContainsRune("-+0123456789.eE", rune(p.input[p.offset])) {
+		p.offset++
+	}
+	number, err := strconv.ParseFloat(p.input[start:p.offset], 64)
+	if err != nil {
+		p.offset = start
+		return 0, p.errorf("invalid number")
+	}
+	return number, nil
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.offset < len(p.input) && p.input[p.offset] == c {
+		p.offset++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, params ...any) error {
+	return &SyntaxError{
+		Offset:  p.offset,
+		Message: fmt.Sprintf(format, params...),
+	}
+}
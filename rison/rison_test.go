@@ -0,0 +1,135 @@
+package rison
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		value    any
+		expected string
+	}{
+		{value: nil, expected: "!n"},
+		{value: true, expected: "!t"},
+		{value: false, expected: "!f"},
+		{value: 0, expected: "0"},
+		{value: -1.5, expected: "-1.5"},
+		{value: 1e21, expected: "1e21"},
+		{value: "", expected: "''"},
+		{value: "a", expected: "a"},
+		{value: "0a", expected: "'0a'"},
+		{value: "-h", expected: "'-h'"},
+		{value: "a-z", expected: "a-z"},
+		{value: "wow!", expected: "'wow!!'"},
+		{value: "can't", expected: "'can!'t'"},
+		{value: "now-15m", expected: "now-15m"},
+		{value: "@timestamp", expected: "'@timestamp'"},
+		{value: "domain.com", expected: "domain.com"},
+		{value: "user@domain.com", expected: "'user@domain.com'"},
+		{value: "US $10", expected: "'US $10'"},
+		{value: []any{}, expected: "!()"},
+		{value: []any{"a", 1, nil}, expected: "!(a,1,!n)"},
+		{value: map[string]any{}, expected: "()"},
+		{value: map[string]any{"b": true, "a": 0, "c": "an object"}, expected: "(a:0,b:!t,c:'an object')"},
+		{value: map[string]any{"id": nil, "type": "/common/document"}, expected: "(id:!n,type:/common/document)"},
+		{
+			value: struct {
+				Time   map[string]string `json:"time"`
+				Filter []any             `json:"filters"`
+			}{Time: map[string]string{"from": "now-15m", "to": "now"}, Filter: []any{}},
+			expected: "(filters:!(),time:(from:now-15m,to:now))",
+		},
+	}
+	for _, testCase := range testCases {
+		s, err := Encode(testCase.value)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, s)
+
+		// Round trip
+		decoded, err := Decode(s)
+		assert.NoError(t, err, s)
+		encoded, err := Encode(decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, s, encoded)
+	}
+
+	_, err := Encode(func() {})
+	assert.Error(t, err)
+}
+
+func TestEncodeObjectAndArray(t *testing.T) {
+	s, err := EncodeObject(map[string]any{"a": 0, "b": "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a:0,b:foo", s)
+	_, err = EncodeObject([]any{1})
+	assert.Error(t, err)
+
+	s, err = EncodeArray([]any{"A", "B", map[string]any{"a": 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, "A,B,(a:1)", s)
+	_, err = EncodeArray("a")
+	assert.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	value, err := Decode("(a:!(1,'x y',!t),b:(c:!n),d:'it!'s')")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"a": []any{float64(1), "x y", true},
+		"b": map[string]any{"c": nil},
+		"d": "it's",
+	}, value)
+
+	object, err := DecodeObject("a:0,b:foo")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": float64(0), "b": "foo"}, object)
+	object, err = DecodeObject("")
+	assert.NoError(t, err)
+	assert.Empty(t, object)
+
+	array, err := DecodeArray("A,B,!t")
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"A", "B", true}, array)
+
+	var state struct {
+		Time struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		} `json:"time"`
+	}
+	err = Unmarshal("(time:(from:now-15m,to:now))", &state)
+	assert.NoError(t, err)
+	assert.Equal(t, "now-15m", state.Time.From)
+
+	// Invalid Rison
+	testCases := []struct {
+		value  string
+		offset int
+	}{
+		{value: "", offset: 0},
+		{value: "(a:1", offset: 4},
+		{value: "(a 1)", offset: 2},
+		{value: "(1:a)", offset: 1},
+		{value: "!x", offset: 1},
+		{value: "'abc", offset: 0},
+		{value: "'a!b'", offset: 3},
+		{value: "!(1 2)", offset: 3},
+		{value: "a)", offset: 1},
+		{value: "1-", offset: 0},
+	}
+	for _, testCase := range testCases {
+		_, err = Decode(testCase.value)
+		syntaxErr := &SyntaxError{}
+		if assert.Error(t, err, testCase.value) && assert.True(t, errors.As(err, &syntaxErr), testCase.value) {
+			assert.Equal(t, testCase.offset, syntaxErr.Offset, testCase.value)
+		}
+	}
+}
+
+func TestQueryEscape(t *testing.T) {
+	assert.Equal(t, "(query:(language:kuery,query:'host.name:+!'web-1!''),time:(from:now-15m,to:now))", QueryEscape("(query:(language:kuery,query:'host.name: !'web-1!''),time:(from:now-15m,to:now))"))
+	assert.Equal(t, "'a%26b%3Dc%23'", QueryEscape("'a&b=c#'"))
+}